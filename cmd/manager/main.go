@@ -1,18 +1,28 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"text/tabwriter"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/cli"
 	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/config"
 	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/ui"
 )
 
+// headless is set when a command runs instead of the TUI.
+var headless bool
+
 func main() {
 	// Set up a panic handler for graceful exit on critical errors.
 	defer func() {
 		if r := recover(); r != nil {
+			if headless {
+				exitWithError(fmt.Errorf("critical error: %v", r))
+			}
 			// Stop Bubble Tea to release the terminal.
 			// We can't pass the program here, so we send a signal.
 			// This is a bit of a hack, but it's the best we can do.
@@ -24,9 +34,47 @@ func main() {
 		}
 	}()
 
-	cfg, isFirstRun, err := config.Load()
+	configPath := flag.String("config", "", "path to the configuration file (overrides $"+config.ConfigEnv+")")
+	portable := flag.Bool("portable", false, "keep the configuration next to the executable")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration and where each value comes from, then exit")
+	flagOverrides := config.RegisterFlags(flag.CommandLine)
+	flag.Usage = usage
+	flag.Parse()
+
+	// Without a command the TUI runs; errors then wait for the user to read
+	// them. Commands are scripted, so they report errors and exit right away.
+	args := flag.Args()
+	headless = len(args) > 0 || *printConfig
+	fail := handleError
+	if headless {
+		fail = exitWithError
+	}
+
+	location, err := config.Locate(config.LocateOptions{Path: *configPath, Portable: *portable})
 	if err != nil {
-		handleError(err)
+		fail(err)
+	}
+
+	cfg, isFirstRun, err := config.Load(location.Path)
+	if err == nil {
+		// The environment overrides the file, and flags override both
+		overrides := append(config.EnvOverrides(), flagOverrides.Overrides()...)
+		err = cfg.ApplyOverrides(overrides)
+	}
+
+	// Commands report a configuration that cannot be loaded themselves, so
+	// that doctor can still diagnose it
+	if len(args) > 0 && !*printConfig {
+		setup := cli.Setup{Config: cfg, Location: location, LoadErr: err, FirstRun: isFirstRun}
+		os.Exit(cli.Run(setup, args, os.Stdout, os.Stderr))
+	}
+	if err != nil {
+		fail(err)
+	}
+
+	if *printConfig {
+		printEffectiveConfig(os.Stdout, location, cfg)
+		return
 	}
 
 	// Create the new controller-based UI
@@ -38,6 +86,38 @@ func main() {
 	}
 }
 
+// printEffectiveConfig writes every setting after overrides, with where its
+// value came from.
+func printEffectiveConfig(w io.Writer, location config.Location, cfg *config.Config) {
+	fmt.Fprintf(w, "Config file: %s (%s)\n\n", location.Path, location.Source)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, v := range cfg.Effective() {
+		value := v.Value
+		if value == "" {
+			value = `""`
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", v.Key, value, v.Source)
+	}
+	tw.Flush()
+}
+
+// usage describes the commands and the flags shared by all of them.
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintf(w, "Usage: %s [flags] [command [arguments]]\n\n", os.Args[0])
+	fmt.Fprintln(w, "Without a command the interactive interface starts. Commands:")
+	cli.PrintCommands(w)
+	fmt.Fprintln(w, "\nRun a command with -h for its own flags. Flags:")
+	flag.PrintDefaults()
+}
+
+// exitWithError reports an error for a command and exits.
+func exitWithError(err error) {
+	fmt.Fprintf(os.Stderr, "error: %v\n", err)
+	os.Exit(cli.ExitError)
+}
+
 // handleError is a centralized function to display errors to the user.
 func handleError(err error) {
 	// Ensure the terminal is in a usable state.
@@ -2,6 +2,8 @@ package app
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 	"time"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -9,7 +11,12 @@ import (
 	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/backup"
 	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/components"
 	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/config"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/discovery"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/doctor"
 	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/layout"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/manifest"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/paths"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/retention"
 	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/services"
 	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/state"
 	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/tui"
@@ -29,7 +36,61 @@ type Application struct {
 	
 	// Configuration and state
 	config   *config.Config
+	// selected holds the IDs of the backups ticked in the delete and trash views
 	selected map[int]struct{}
+
+	// Integrity check state
+	verifying     bool
+	verifyResults []backup.VerifyResult
+
+	// Retention pruning state
+	prunePlan []retention.Decision
+
+	// Result of the last database/disk reconciliation
+	reconcileReport *backup.ReconcileReport
+
+	// Diagnostics state
+	checkingHealth bool
+	healthReport   *doctor.Report
+
+	// Backup whose note or tags are being edited, and the list it came from
+	editing      backup.Backup
+	editReturnTo state.ViewState
+
+	// Backups being exported to a bundle, and the list they came from
+	exporting      []backup.Backup
+	exportReturnTo state.ViewState
+
+	// Bundle with backups of games that match none, waiting for the user to
+	// pick a game for them, and what happened to its other backups
+	importPath    string
+	importResults []backup.ImportResult
+
+	// Whether the trash view is asking to confirm a permanent delete
+	confirmingPurge bool
+
+	// Backup list position and filter of each game, by game ID
+	listPositions map[string]listPosition
+
+	// Files the active game's filter selects, and the name of the backup to
+	// create once the user has confirmed them
+	filterPreview  *backup.FilterPreview
+	pendingBackup  string
+	previewCreates bool
+
+	// Games found during first-run setup, and the one the user picked
+	discovering bool
+	candidates  []discovery.Candidate
+	chosen      *discovery.Candidate
+
+	// Whether a save-location manifest is being read
+	importingManifest bool
+
+	// Problems found in the configuration at startup, the one being
+	// corrected, and whether any field has been changed
+	problems     config.ValidationErrors
+	fixing       config.FieldError
+	configEdited bool
 	
 	// Window dimensions
 	width  int
@@ -44,11 +105,19 @@ func NewApplication(cfg *config.Config, isFirstRun bool) *Application {
 	// Initialize core components
 	var initialState state.ViewState
 	if isFirstRun {
-		initialState = state.FirstRunView
+		initialState = state.FirstRunDiscoverView
 	} else {
 		initialState = state.InitializingView
 	}
 	
+	// A broken configuration is fixed before anything else happens
+	var problems config.ValidationErrors
+	if !isFirstRun {
+		if problems = cfg.Validate(); len(problems) > 0 {
+			initialState = state.FixConfigView
+		}
+	}
+	
 	stateManager := state.NewStateManager(initialState)
 	backupService := services.NewBackupService(nil, cfg)
 	notificationManager := components.NewNotificationManager()
@@ -66,7 +135,7 @@ func NewApplication(cfg *config.Config, isFirstRun bool) *Application {
 		textInput.Width = 50 // Will be updated on first WindowSizeMsg
 	}
 	
-	return &Application{
+	app := &Application{
 		stateManager:        stateManager,
 		backupService:       backupService,
 		notificationManager: notificationManager,
@@ -75,14 +144,61 @@ func NewApplication(cfg *config.Config, isFirstRun bool) *Application {
 		textInput:           textInput,
 		config:              cfg,
 		selected:            selected,
+		listPositions:       make(map[string]listPosition),
+		discovering:         isFirstRun,
+		problems:            problems,
+	}
+	return app
+}
+
+// backupFilter returns the list filter for items. Lists of backups are
+// filtered with the selector language, so the filter box understands queries
+// like "latest tag:boss". Other lists, and queries that don't parse yet while
+// being typed, use fuzzy matching. The list filters away from the UI
+// goroutine, so the filter works on its own copy of the backups and games.
+func (app *Application) backupFilter(items []list.Item) list.FilterFunc {
+	backups := make([]backup.Backup, len(items))
+	for i, item := range items {
+		b, ok := components.BackupOf(item)
+		if !ok {
+			return list.DefaultFilter
+		}
+		backups[i] = b
+	}
+	cfg := *app.config
+	cfg.Games = append([]config.Game(nil), cfg.Games...)
+	service := services.NewBackupService(nil, &cfg)
+	
+	return func(term string, targets []string) []list.Rank {
+		if len(backups) != len(targets) {
+			return list.DefaultFilter(term, targets)
+		}
+		indexes, err := service.FilterBackups(term, backups)
+		if err != nil {
+			return list.DefaultFilter(term, targets)
+		}
+		ranks := make([]list.Rank, len(indexes))
+		for i, index := range indexes {
+			ranks[i] = list.Rank{Index: index}
+		}
+		return ranks
 	}
 }
 
+// setItems replaces the list contents, along with the filter for them
+func (app *Application) setItems(items []list.Item) {
+	app.list.Filter = app.backupFilter(items)
+	app.list.SetItems(items)
+}
+
 // Init initializes the application
 func (app *Application) Init() tea.Cmd {
 	if app.stateManager.IsInState(state.InitializingView) {
 		return app.initializeDatabase
 	}
+	if app.stateManager.IsInState(state.FirstRunDiscoverView) {
+		return app.discoverGames
+	}
 	return nil
 }
 
@@ -96,6 +212,11 @@ func (app *Application) TransitionToState(newState state.ViewState) {
 	app.stateManager.TransitionTo(newState)
 }
 
+// IsInAnyState checks if the application is in any of the specified states
+func (app *Application) IsInAnyState(states ...state.ViewState) bool {
+	return app.stateManager.IsInAnyState(states...)
+}
+
 // ShowNotification displays a notification message
 func (app *Application) ShowNotification(message string) tea.Cmd {
 	return app.notificationManager.Show(message)
@@ -156,7 +277,7 @@ func (app *Application) RefreshBackupList(title string) tea.Cmd {
 	if err != nil {
 		return func() tea.Msg { return err }
 	}
-	app.list.SetItems(items)
+	app.setItems(items)
 	
 	// Update list size using current window dimensions
 	listHeight := layout.CalculateListHeight(app.height)
@@ -164,6 +285,15 @@ func (app *Application) RefreshBackupList(title string) tea.Cmd {
 	return nil
 }
 
+// SetListItems replaces the list contents with the given items and title
+func (app *Application) SetListItems(title string, items []list.Item) {
+	app.list.Title = title
+	app.setItems(items)
+
+	listHeight := layout.CalculateListHeight(app.height)
+	app.list.SetSize(app.width, listHeight)
+}
+
 // initializeDatabase initializes the backup database
 func (app *Application) initializeDatabase() tea.Msg {
 	err := app.backupService.InitializeDatabase()
@@ -171,6 +301,20 @@ func (app *Application) initializeDatabase() tea.Msg {
 		return err
 	}
 	
+	// Permanently delete backups that have been in the trash too long
+	if _, err := app.backupService.PurgeExpiredTrash(); err != nil {
+		return err
+	}
+	
+	// Optionally check for drift between the database and the disk
+	if app.config.ReconcileOnStartup {
+		report, err := app.backupService.Reconcile()
+		if err != nil {
+			return err
+		}
+		return DatabaseInitializedMsg{Reconcile: report}
+	}
+	
 	// Return a message indicating database is ready
 	return DatabaseInitializedMsg{}
 }
@@ -210,18 +354,67 @@ func (app *Application) ClearSelections() {
 	app.selected = make(map[int]struct{})
 }
 
-// GetSelections returns the current selections
+// GetSelections returns the IDs of the selected backups
 func (app *Application) GetSelections() map[int]struct{} {
 	return app.selected
 }
 
+// ToggleSelection ticks or unticks the highlighted backup
+func (app *Application) ToggleSelection() {
+	b, ok := components.BackupOf(app.list.SelectedItem())
+	if !ok {
+		return
+	}
+	if _, exists := app.selected[b.ID]; exists {
+		delete(app.selected, b.ID)
+	} else {
+		app.selected[b.ID] = struct{}{}
+	}
+}
+
+// SelectAllVisible ticks every backup the list shows, so only the matches
+// are ticked while a filter is applied
+func (app *Application) SelectAllVisible() {
+	for _, item := range app.list.VisibleItems() {
+		if b, ok := components.BackupOf(item); ok {
+			app.selected[b.ID] = struct{}{}
+		}
+	}
+}
+
 // GetStyles returns the application styles
 func (app *Application) GetStyles() *tui.Styles {
 	return app.styles
 }
 
 // DatabaseInitializedMsg indicates the database is ready
-type DatabaseInitializedMsg struct{}
+type DatabaseInitializedMsg struct {
+	// Reconcile holds the startup reconciliation report, if one was run
+	Reconcile *backup.ReconcileReport
+}
+
+// DiscoveryCompletedMsg carries the installed games found for first-run setup
+type DiscoveryCompletedMsg struct {
+	Candidates []discovery.Candidate
+}
+
+// ManifestResolvedMsg carries the game profiles a save-location manifest
+// suggests for this machine
+type ManifestResolvedMsg struct {
+	Path     string
+	Profiles []manifest.Profile
+	Err      error
+}
+
+// HealthCheckedMsg carries the report of a diagnostics run
+type HealthCheckedMsg struct {
+	Report *doctor.Report
+}
+
+// VerifyCompletedMsg carries the results of a backup integrity check
+type VerifyCompletedMsg struct {
+	Results []backup.VerifyResult
+}
 
 // GetTextInput returns the text input component
 func (app *Application) GetTextInput() *textinput.Model {
@@ -258,25 +451,17 @@ func (app *Application) GetConfig() *config.Config {
 
 // CreateBackup creates a new backup with the given name
 func (app *Application) CreateBackup(name string) error {
-	return app.backupService.CreateBackup(name)
+	_, err := app.backupService.CreateBackup(name)
+	return err
 }
 
 // RestoreSelectedBackup restores the currently selected backup
 func (app *Application) RestoreSelectedBackup() error {
-	selectedIndex := app.list.Index()
-	items := app.list.Items()
-	
-	if selectedIndex >= len(items) {
+	b, ok := app.SelectedBackup()
+	if !ok {
 		return fmt.Errorf("no backup selected")
 	}
-	
-	// Convert the selected item to a backup
-	if listItem, ok := items[selectedIndex].(components.ListItem); ok {
-		backup := backup.Backup(listItem)
-		return app.backupService.RestoreBackup(backup)
-	}
-	
-	return fmt.Errorf("invalid backup selection")
+	return app.backupService.RestoreBackup(b)
 }
 
 // DeleteSelectedBackups deletes the currently selected backups
@@ -291,9 +476,18 @@ func (app *Application) DeleteSelectedBackups() error {
 	return app.backupService.DeleteBackups(selectedBackups)
 }
 
-// UpdateSavePath updates the save path in the configuration
+// ActiveGame returns the game profile the application is working on, or nil if none is configured
+func (app *Application) ActiveGame() *config.Game {
+	return app.config.ActiveGameProfile()
+}
+
+// UpdateSavePath updates the active game's save path in the configuration
 func (app *Application) UpdateSavePath(newPath string) error {
-	app.config.SavePath = newPath
+	game := app.ActiveGame()
+	if game == nil {
+		return fmt.Errorf("no game configured")
+	}
+	game.SavePath = newPath
 	return app.config.Save()
 }
 
@@ -303,35 +497,698 @@ func (app *Application) UpdateBackupDir(newDir string) error {
 	return app.config.Save()
 }
 
-// ToggleAutoBackup toggles the auto-backup setting
+// ToggleAutoBackup toggles the active game's auto-backup setting
 func (app *Application) ToggleAutoBackup() error {
-	app.config.AutoBackup = !app.config.AutoBackup
+	game := app.ActiveGame()
+	if game == nil {
+		return fmt.Errorf("no game configured")
+	}
+	game.AutoBackup = !game.AutoBackup
 	return app.config.Save()
 }
 
 // RestoreSelectedBackupWithAutoBackup restores the selected backup with optional auto-backup
 func (app *Application) RestoreSelectedBackupWithAutoBackup() error {
-	selectedIndex := app.list.Index()
-	items := app.list.Items()
-	
-	if selectedIndex >= len(items) {
+	b, ok := app.SelectedBackup()
+	if !ok {
 		return fmt.Errorf("no backup selected")
 	}
-	
-	// Convert the selected item to a backup
-	if listItem, ok := items[selectedIndex].(components.ListItem); ok {
-		backupToRestore := backup.Backup(listItem)
-		
-		// Create auto-backup before restoring if enabled
-		if app.config.AutoBackup {
-			autoBackupName := fmt.Sprintf("Backup_%s", time.Now().Format("2006-01-02_15-04-05"))
-			if err := app.backupService.CreateBackup(autoBackupName); err != nil {
-				return fmt.Errorf("failed to create auto-backup: %v", err)
-			}
+	return app.backupService.RestoreBackupWithAutoBackup(b)
+}
+
+// StartVerify clears previous results and returns a command that checks
+// the integrity of every backup
+func (app *Application) StartVerify() tea.Cmd {
+	app.verifying = true
+	app.verifyResults = nil
+	app.SetListItems("Verification Results", nil)
+	return func() tea.Msg {
+		results, err := app.backupService.VerifyBackups()
+		if err != nil {
+			return err
 		}
-		
-		return app.backupService.RestoreBackup(backupToRestore)
+		return VerifyCompletedMsg{Results: results}
 	}
-	
-	return fmt.Errorf("invalid backup selection")
-}
\ No newline at end of file
+}
+
+// SetVerifyResults stores the results of a completed integrity check
+func (app *Application) SetVerifyResults(results []backup.VerifyResult) {
+	app.verifying = false
+	app.verifyResults = results
+}
+
+// IsVerifying returns true while an integrity check is running
+func (app *Application) IsVerifying() bool {
+	return app.verifying
+}
+
+// GetVerifyResults returns the results of the last integrity check
+func (app *Application) GetVerifyResults() []backup.VerifyResult {
+	return app.verifyResults
+}
+
+// StartHealthCheck returns a command that runs the diagnostics in the
+// background
+func (app *Application) StartHealthCheck() tea.Cmd {
+	app.checkingHealth = true
+	app.healthReport = nil
+	opts := doctor.Options{Config: app.config, Location: config.Location{Path: app.config.Path()}}
+	return func() tea.Msg {
+		return HealthCheckedMsg{Report: doctor.Run(opts)}
+	}
+}
+
+// SetHealthReport stores the report of completed diagnostics
+func (app *Application) SetHealthReport(report *doctor.Report) {
+	app.checkingHealth = false
+	app.healthReport = report
+}
+
+// IsCheckingHealth returns true while the diagnostics are running
+func (app *Application) IsCheckingHealth() bool {
+	return app.checkingHealth
+}
+
+// GetHealthReport returns the report of the last diagnostics run
+func (app *Application) GetHealthReport() *doctor.Report {
+	return app.healthReport
+}
+
+// PlanPrune computes which backups the retention policy would remove and
+// shows the plan in the list
+func (app *Application) PlanPrune() error {
+	plan, err := app.backupService.PlanPrune()
+	if err != nil {
+		return err
+	}
+	app.prunePlan = plan
+
+	items := make([]list.Item, len(plan))
+	for i, d := range plan {
+		items[i] = components.PruneItem(d)
+	}
+	app.SetListItems("Retention Preview", items)
+	return nil
+}
+
+// GetPrunePlan returns the last computed retention plan
+func (app *Application) GetPrunePlan() []retention.Decision {
+	return app.prunePlan
+}
+
+// ExecutePrune deletes the backups marked for removal in the current plan
+func (app *Application) ExecutePrune() (int, error) {
+	count, err := app.backupService.Prune(app.prunePlan)
+	app.prunePlan = nil
+	return count, err
+}
+
+// SelectedBackup returns the backup currently highlighted in the list
+func (app *Application) SelectedBackup() (backup.Backup, bool) {
+	if listItem, ok := app.list.SelectedItem().(components.ListItem); ok {
+		return backup.Backup(listItem), true
+	}
+	return backup.Backup{}, false
+}
+
+// StartEditNote opens the note editor for the highlighted backup
+func (app *Application) StartEditNote() {
+	b, ok := app.SelectedBackup()
+	if !ok {
+		return
+	}
+	app.editing = b
+	app.editReturnTo = app.stateManager.Current()
+	app.TransitionToState(state.EditNoteView)
+	app.SetTextInputPlaceholder("e.g. before final boss")
+	app.SetTextInputCharLimit(256)
+	app.textInput.SetValue(b.Note)
+	app.textInput.CursorEnd()
+	app.FocusTextInput()
+}
+
+// StartEditTags opens the tag editor for the highlighted backup
+func (app *Application) StartEditTags() {
+	b, ok := app.SelectedBackup()
+	if !ok {
+		return
+	}
+	app.editing = b
+	app.editReturnTo = app.stateManager.Current()
+	app.TransitionToState(state.EditTagsView)
+	app.SetTextInputPlaceholder("comma-separated, e.g. milestone, 100% completion")
+	app.SetTextInputCharLimit(256)
+	app.textInput.SetValue(strings.Join(b.Tags, ", "))
+	app.textInput.CursorEnd()
+	app.FocusTextInput()
+}
+
+// GetEditingBackup returns the backup whose note or tags are being edited
+func (app *Application) GetEditingBackup() backup.Backup {
+	return app.editing
+}
+
+// SaveNote stores the edited note and returns to the list it was opened from
+func (app *Application) SaveNote(note string) error {
+	if err := app.backupService.SetNote(app.editing, note); err != nil {
+		return err
+	}
+	return app.FinishEdit()
+}
+
+// SaveTags stores the edited tags and returns to the list it was opened from
+func (app *Application) SaveTags(tags string) error {
+	if err := app.backupService.SetTags(app.editing, backup.ParseTags(tags)); err != nil {
+		return err
+	}
+	return app.FinishEdit()
+}
+
+// FinishEdit returns to the list the editor was opened from, keeping the
+// highlighted item and any active filter
+func (app *Application) FinishEdit() error {
+	app.TransitionToState(app.editReturnTo)
+	index := app.list.Index()
+	items, err := app.backupService.GetBackupItems()
+	if err != nil {
+		return err
+	}
+	app.setItems(items)
+	app.list.Select(index)
+	return nil
+}
+
+// StartExport asks where to export the backups picked in the list: those
+// ticked in the delete view, or else the highlighted one
+func (app *Application) StartExport() {
+	var backups []backup.Backup
+	if app.stateManager.Current() == state.DeletingView {
+		backups = app.backupService.GetSelectedBackups(app.list.Items(), app.selected)
+	}
+	if len(backups) == 0 {
+		b, ok := app.SelectedBackup()
+		if !ok {
+			return
+		}
+		backups = []backup.Backup{b}
+	}
+	app.exporting = backups
+	app.exportReturnTo = app.stateManager.Current()
+	app.TransitionToState(state.ExportBundleView)
+	app.SetTextInputPlaceholder("Enter bundle path, e.g. ~/backups.tar")
+	app.SetTextInputCharLimit(0)
+	app.textInput.SetValue(filepath.Join("~", backup.BundleName(backups[0].GameID, time.Now())))
+	app.textInput.CursorEnd()
+	app.FocusTextInput()
+}
+
+// GetExporting returns the backups being exported
+func (app *Application) GetExporting() []backup.Backup {
+	return app.exporting
+}
+
+// ExportBackups writes the backups being exported to a bundle at path and
+// returns to the list they were picked from
+func (app *Application) ExportBackups(path string) (string, error) {
+	expanded, err := paths.Expand(path, paths.Options{})
+	if err != nil {
+		return "", err
+	}
+	if err := app.backupService.ExportBackups(expanded, app.exporting); err != nil {
+		return "", err
+	}
+	app.CancelExport()
+	return expanded, nil
+}
+
+// CancelExport returns to the list the export was started from
+func (app *Application) CancelExport() {
+	app.exporting = nil
+	app.TransitionToState(app.exportReturnTo)
+}
+
+// ImportBundle adds the backups in the bundle at path to the games they
+// belong to, renaming any whose name is taken, and returns what happened to
+// each. Backups of games that match none are held back until a game is
+// picked for them with ImportUnmatched.
+func (app *Application) ImportBundle(path string) ([]backup.ImportResult, error) {
+	app.ClearPendingImport()
+	expanded, err := paths.Expand(path, paths.Options{})
+	if err != nil {
+		return nil, err
+	}
+	results, err := app.backupService.ImportBundle(expanded, backup.CollisionRename, "")
+	if err != nil {
+		return results, err
+	}
+	for _, r := range results {
+		if r.Status == backup.ImportNoGame {
+			app.importPath, app.importResults = expanded, results
+			break
+		}
+	}
+	return results, nil
+}
+
+// ImportUnmatched adds the held back backups of the last import to the game
+// with the given ID and returns what happened to every backup of the bundle
+func (app *Application) ImportUnmatched(gameID string) ([]backup.ImportResult, error) {
+	path, results := app.importPath, app.importResults
+	app.ClearPendingImport()
+	if results == nil {
+		return nil, fmt.Errorf("no import is waiting for a game")
+	}
+	added, err := app.backupService.ImportUnmatched(path, backup.CollisionRename, gameID)
+	if err != nil {
+		return nil, err
+	}
+	// Both imports list the bundle's backups in the same order
+	merged := make([]backup.ImportResult, len(results))
+	for i, r := range results {
+		merged[i] = r
+		if r.Status == backup.ImportNoGame && i < len(added) {
+			merged[i] = added[i]
+		}
+	}
+	return merged, nil
+}
+
+// GetPendingImport returns the results of an import whose unmatched backups
+// are waiting for a game, or nil if there is none
+func (app *Application) GetPendingImport() []backup.ImportResult {
+	return app.importResults
+}
+
+// ClearPendingImport drops the backups held back by the last import
+func (app *Application) ClearPendingImport() {
+	app.importPath, app.importResults = "", nil
+}
+
+// RunReconcile compares the database with the backup directory and stores the report
+func (app *Application) RunReconcile() error {
+	report, err := app.backupService.Reconcile()
+	if err != nil {
+		return err
+	}
+	app.reconcileReport = report
+	return nil
+}
+
+// SetReconcileReport stores a reconciliation report produced elsewhere
+func (app *Application) SetReconcileReport(report *backup.ReconcileReport) {
+	app.reconcileReport = report
+}
+
+// GetReconcileReport returns the last reconciliation report
+func (app *Application) GetReconcileReport() *backup.ReconcileReport {
+	return app.reconcileReport
+}
+
+// AdoptOrphans records the stray files from the last report and rescans
+func (app *Application) AdoptOrphans() (int, error) {
+	orphans := app.reconcileReport.Orphans
+	if err := app.backupService.AdoptOrphans(orphans); err != nil {
+		return 0, err
+	}
+	return len(orphans), app.RunReconcile()
+}
+
+// DropMissingBackups removes rows whose data is gone and rescans
+func (app *Application) DropMissingBackups() (int, error) {
+	missing := app.reconcileReport.Missing
+	if err := app.backupService.DropMissing(missing); err != nil {
+		return 0, err
+	}
+	return len(missing), app.RunReconcile()
+}
+
+// RemoveUnusedObjects deletes unreferenced objects from the last report and rescans
+func (app *Application) RemoveUnusedObjects() (int, error) {
+	unused := app.reconcileReport.UnusedObjects
+	if err := app.backupService.RemoveUnusedObjects(unused); err != nil {
+		return 0, err
+	}
+	return len(unused), app.RunReconcile()
+}
+
+// RefreshTrashList fills the list with the backups in the trash
+func (app *Application) RefreshTrashList() tea.Cmd {
+	items, err := app.backupService.GetTrashItems()
+	if err != nil {
+		return func() tea.Msg { return err }
+	}
+	app.SetListItems("Trash", items)
+	return nil
+}
+
+// RestoreSelectedFromTrash takes the selected backups out of the trash
+func (app *Application) RestoreSelectedFromTrash() (int, error) {
+	backups := app.backupService.GetSelectedBackups(app.list.Items(), app.selected)
+	if len(backups) == 0 {
+		return 0, nil
+	}
+	if err := app.backupService.RestoreFromTrash(backups); err != nil {
+		return 0, err
+	}
+	return len(backups), nil
+}
+
+// PurgeSelectedFromTrash permanently deletes the selected backups in the trash
+func (app *Application) PurgeSelectedFromTrash() (int, error) {
+	app.confirmingPurge = false
+	backups := app.backupService.GetSelectedBackups(app.list.Items(), app.selected)
+	if len(backups) == 0 {
+		return 0, nil
+	}
+	if err := app.backupService.PurgeBackups(backups); err != nil {
+		return 0, err
+	}
+	return len(backups), nil
+}
+
+// SetConfirmingPurge sets whether the trash view is asking for purge confirmation
+func (app *Application) SetConfirmingPurge(confirming bool) {
+	app.confirmingPurge = confirming
+}
+
+// IsConfirmingPurge returns true if the trash view is asking for purge confirmation
+func (app *Application) IsConfirmingPurge() bool {
+	return app.confirmingPurge
+}
+
+// listPosition remembers where the user was in a game's backup list
+type listPosition struct {
+	index  int
+	filter string
+}
+
+// GetGames returns the configured game profiles
+func (app *Application) GetGames() []config.Game {
+	return app.config.Games
+}
+
+// SwitchGame makes the game with the given ID active and saves the choice
+func (app *Application) SwitchGame(id string) error {
+	if !app.config.SetActiveGame(id) {
+		return fmt.Errorf("unknown game: %s", id)
+	}
+	app.ClearSelections()
+	return app.config.Save()
+}
+
+// CycleGame switches to the next game in the configured order, wrapping around
+func (app *Application) CycleGame() error {
+	games := app.config.Games
+	if len(games) < 2 {
+		return nil
+	}
+	next := 0
+	for i, g := range games {
+		if g.ID == app.config.ActiveGame {
+			next = (i + 1) % len(games)
+			break
+		}
+	}
+	return app.SwitchGame(games[next].ID)
+}
+
+// RememberListPosition stores the list cursor and filter for the active game
+func (app *Application) RememberListPosition() {
+	game := app.ActiveGame()
+	if game == nil {
+		return
+	}
+	pos := listPosition{index: app.list.Index()}
+	if app.list.FilterState() != list.Unfiltered {
+		pos.filter = app.list.FilterValue()
+	}
+	app.listPositions[game.ID] = pos
+}
+
+// RestoreListPosition reapplies the list cursor and filter last stored for the active game
+func (app *Application) RestoreListPosition() {
+	game := app.ActiveGame()
+	if game == nil {
+		return
+	}
+	pos := app.listPositions[game.ID]
+	if pos.filter != "" {
+		app.list.SetFilterText(pos.filter)
+	} else {
+		app.list.ResetFilter()
+	}
+	if pos.index >= len(app.list.VisibleItems()) {
+		pos.index = 0
+	}
+	app.list.Select(pos.index)
+}
+
+// NeedsFilterPreview returns true if the next backup is the active game's
+// first backup of a save directory, which should be previewed before it is made
+func (app *Application) NeedsFilterPreview() (bool, error) {
+	return app.backupService.IsFirstDirectoryBackup()
+}
+
+// StartFilterPreview lists the files the active game's filter selects. If
+// create is true, confirming the preview creates a backup with the given name.
+func (app *Application) StartFilterPreview(name string, create bool) error {
+	preview, err := app.backupService.PreviewFilter()
+	if err != nil {
+		return err
+	}
+	app.filterPreview = preview
+	app.pendingBackup = name
+	app.previewCreates = create
+
+	items := make([]list.Item, 0, len(preview.Included)+len(preview.Excluded))
+	for _, f := range preview.Included {
+		items = append(items, components.PreviewItem{File: f, Included: true})
+	}
+	for _, f := range preview.Excluded {
+		items = append(items, components.PreviewItem{File: f})
+	}
+	app.TransitionToState(state.FilterPreviewView)
+	app.SetListDelegate(components.NewNormalItemDelegate())
+	app.SetListItems("Files in "+app.ActiveGame().Name+" save", items)
+	app.list.ResetFilter()
+	app.ResetListSelection()
+	return nil
+}
+
+// GetFilterPreview returns the last filter preview
+func (app *Application) GetFilterPreview() *backup.FilterPreview {
+	return app.filterPreview
+}
+
+// PreviewCreatesBackup returns true if confirming the preview creates a backup
+func (app *Application) PreviewCreatesBackup() bool {
+	return app.previewCreates
+}
+
+// ConfirmFilterPreview creates the backup the preview was shown for and
+// returns its requested name
+func (app *Application) ConfirmFilterPreview() (string, error) {
+	name := app.pendingBackup
+	app.pendingBackup = ""
+	app.previewCreates = false
+	return name, app.CreateBackup(name)
+}
+
+// discoverGames looks for installed games to offer during first-run setup
+func (app *Application) discoverGames() tea.Msg {
+	return DiscoveryCompletedMsg{Candidates: discovery.Discover()}
+}
+
+// SetCandidates stores the discovered games and lists them. Without any,
+// setup continues with typing the save path by hand.
+func (app *Application) SetCandidates(candidates []discovery.Candidate) {
+	app.discovering = false
+	app.candidates = candidates
+	if len(candidates) == 0 {
+		app.ChooseCandidate(nil)
+		return
+	}
+
+	items := make([]list.Item, len(candidates))
+	for i, c := range candidates {
+		items[i] = components.CandidateItem(c)
+	}
+	app.SetListDelegate(components.NewNormalItemDelegate())
+	app.SetListItems("Installed games", items)
+	app.list.ResetFilter()
+	app.ResetListSelection()
+}
+
+// IsDiscovering returns true while looking for installed games
+func (app *Application) IsDiscovering() bool {
+	return app.discovering
+}
+
+// HasCandidates returns true if discovery found any installed games
+func (app *Application) HasCandidates() bool {
+	return len(app.candidates) > 0
+}
+
+// ChooseCandidate moves on to entering the save path, pre-filled with the
+// suggestion for the chosen game. A nil candidate starts from an empty path.
+func (app *Application) ChooseCandidate(candidate *discovery.Candidate) {
+	app.chosen = candidate
+	app.TransitionToState(state.FirstRunView)
+	app.SetTextInputPlaceholder("Enter your game's save file path")
+	app.ClearTextInput()
+	if candidate != nil {
+		app.textInput.SetValue(candidate.SavePath)
+		app.textInput.CursorEnd()
+	}
+	app.FocusTextInput()
+}
+
+// ChosenCandidate returns the discovered game picked during first-run
+// setup, or nil if the save path is being entered by hand
+func (app *Application) ChosenCandidate() *discovery.Candidate {
+	return app.chosen
+}
+
+// StartImportManifest returns a command that reads a save-location manifest
+// and works out which of its games have saves on this machine
+func (app *Application) StartImportManifest(path string) tea.Cmd {
+	app.importingManifest = true
+	return func() tea.Msg {
+		expanded, err := paths.Expand(path, paths.Options{})
+		if err != nil {
+			return ManifestResolvedMsg{Path: path, Err: err}
+		}
+		m, err := manifest.Load(expanded)
+		if err != nil {
+			return ManifestResolvedMsg{Path: path, Err: err}
+		}
+		return ManifestResolvedMsg{Path: path, Profiles: m.Resolve(discovery.Discover())}
+	}
+}
+
+// IsImportingManifest returns true while a manifest is being read
+func (app *Application) IsImportingManifest() bool {
+	return app.importingManifest
+}
+
+// FinishImportManifest clears the importing state after a failed import
+func (app *Application) FinishImportManifest() {
+	app.importingManifest = false
+}
+
+// AddManifestProfiles adds the games from a manifest that aren't configured
+// yet, matching existing games by name or save location, and saves the
+// configuration. It returns the names of the games added.
+func (app *Application) AddManifestProfiles(path string, profiles []manifest.Profile) ([]string, error) {
+	app.importingManifest = false
+	var added []string
+	for _, p := range profiles {
+		if app.hasGame(p) {
+			continue
+		}
+		game := app.config.AddGame(p.Name, p.SavePath)
+		game.Prefix = p.Prefix
+		game.Include = p.Include
+		added = append(added, p.Name)
+	}
+
+	app.config.Manifest = path
+	return added, app.config.Save()
+}
+
+// hasGame reports whether a game like p is already configured
+func (app *Application) hasGame(p manifest.Profile) bool {
+	for _, g := range app.config.Games {
+		if strings.EqualFold(g.Name, p.Name) || (g.SavePath == p.SavePath && g.Prefix == p.Prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetConfigProblems returns the problems left in the configuration
+func (app *Application) GetConfigProblems() config.ValidationErrors {
+	return app.problems
+}
+
+// ShowConfigProblems lists the problems left in the configuration
+func (app *Application) ShowConfigProblems() {
+	items := make([]list.Item, len(app.problems))
+	for i, p := range app.problems {
+		item := components.ProblemItem{Problem: p}
+		if p.Game >= 0 && p.Game < len(app.config.Games) {
+			item.Game = app.config.Games[p.Game].Name
+		}
+		items[i] = item
+	}
+	app.SetListDelegate(components.NewNormalItemDelegate())
+	app.SetListItems("Configuration problems", items)
+}
+
+// RecheckConfig validates the configuration again and lists what is left
+func (app *Application) RecheckConfig() {
+	app.problems = app.config.Validate()
+	app.ShowConfigProblems()
+}
+
+// StartFixField opens an editor for the highlighted configuration problem,
+// or explains why the field can't be edited here
+func (app *Application) StartFixField() tea.Cmd {
+	item, ok := app.list.SelectedItem().(components.ProblemItem)
+	if !ok {
+		return nil
+	}
+	if err := app.config.Editable(item.Problem); err != nil {
+		return app.ShowNotification(err.Error())
+	}
+	app.fixing = item.Problem
+	app.TransitionToState(state.FixConfigFieldView)
+	app.SetTextInputPlaceholder("Enter a new value...")
+	app.SetTextInputCharLimit(0)
+	app.textInput.SetValue(app.config.FieldValue(item.Problem))
+	app.textInput.CursorEnd()
+	app.FocusTextInput()
+	return nil
+}
+
+// GetFixingField returns the configuration field being corrected
+func (app *Application) GetFixingField() config.FieldError {
+	return app.fixing
+}
+
+// SaveFixedField sets the field being corrected and checks the configuration
+// again. Once no problems are left, the configuration is saved and startup
+// continues with the returned command.
+func (app *Application) SaveFixedField(value string) (tea.Cmd, error) {
+	if err := app.config.SetField(app.fixing, value); err != nil {
+		// Leave the editor open so another value can be tried
+		return app.ShowNotification(err.Error()), nil
+	}
+	app.configEdited = true
+
+	app.TransitionToState(state.FixConfigView)
+	app.RecheckConfig()
+	if len(app.problems) > 0 {
+		return nil, nil
+	}
+	return app.ContinueStartup()
+}
+
+// CancelFixField closes the field editor without changing anything
+func (app *Application) CancelFixField() {
+	app.TransitionToState(state.FixConfigView)
+}
+
+// ContinueStartup saves any corrections and initializes the database,
+// even if problems with individual games remain
+func (app *Application) ContinueStartup() (tea.Cmd, error) {
+	if app.configEdited {
+		if err := app.config.Save(); err != nil {
+			return nil, err
+		}
+		app.configEdited = false
+	}
+	app.problems = nil
+	app.TransitionToState(state.InitializingView)
+	return app.initializeDatabase, nil
+}
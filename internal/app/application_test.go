@@ -0,0 +1,113 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/backup"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/components"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/config"
+)
+
+// selectedIDs returns the IDs of the backups the delete and trash views act on.
+func selectedIDs(app *Application) []int {
+	var ids []int
+	for _, b := range app.backupService.GetSelectedBackups(app.list.Items(), app.GetSelections()) {
+		ids = append(ids, b.ID)
+	}
+	return ids
+}
+
+func TestSelectionsFollowTheFilter(t *testing.T) {
+	backups := []backup.Backup{
+		{ID: 7, Name: "start"},
+		{ID: 8, Name: "before boss"},
+		{ID: 9, Name: "after boss"},
+	}
+	tests := []struct {
+		name  string
+		items func(b backup.Backup) list.Item
+	}{
+		{"backups", func(b backup.Backup) list.Item { return components.ListItem(b) }},
+		{"trash", func(b backup.Backup) list.Item { return components.TrashItem{Backup: b} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := NewApplication(&config.Config{}, false)
+			items := make([]list.Item, len(backups))
+			for i, b := range backups {
+				items[i] = tt.items(b)
+			}
+			app.SetListItems(tt.name, items)
+
+			// The first match is the second item in the list
+			app.list.SetFilterText("boss")
+			app.ToggleSelection()
+			if got, want := selectedIDs(app), []int{8}; !reflect.DeepEqual(got, want) {
+				t.Errorf("after ticking the highlighted match, selected = %v, want %v", got, want)
+			}
+
+			app.SelectAllVisible()
+			if got, want := selectedIDs(app), []int{8, 9}; !reflect.DeepEqual(got, want) {
+				t.Errorf("after ticking every match, selected = %v, want %v", got, want)
+			}
+
+			app.ToggleSelection()
+			if got, want := selectedIDs(app), []int{9}; !reflect.DeepEqual(got, want) {
+				t.Errorf("after unticking the highlighted match, selected = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestListFilter(t *testing.T) {
+	backups := []list.Item{
+		components.ListItem{ID: 3, Name: "after boss", GameID: "hades", Tags: []string{"boss"}},
+		components.ListItem{ID: 2, Name: "before boss", GameID: "hades"},
+		components.ListItem{ID: 1, Name: "start", GameID: "hades"},
+	}
+	games := []list.Item{
+		components.GameItem{Game: config.Game{ID: "hades", Name: "Hades"}},
+		components.GameItem{Game: config.Game{ID: "celeste", Name: "Celeste"}},
+	}
+	tests := []struct {
+		name  string
+		items []list.Item
+		term  string
+		want  []int // indexes of the matches
+	}{
+		{"selector", backups, "tag:boss", []int{0}},
+		{"latest", backups, "boss latest", []int{0}},
+		{"game", backups, "game:hades start", []int{2}},
+		{"unknown game falls back to fuzzy matching", backups, "game:portal", nil},
+		{"unfinished query falls back to fuzzy matching", backups, `name:"bef`, nil},
+		{"other lists use fuzzy matching", games, "cel", []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.AddGame("Hades", "/saves")
+			app := NewApplication(cfg, false)
+			app.SetListItems(tt.name, tt.items)
+			filter := app.list.Filter
+
+			// The filter keeps working on the items it was made for while the
+			// UI goroutine moves on
+			app.SetListItems("other", games)
+			cfg.Games = nil
+
+			targets := make([]string, len(tt.items))
+			for i, item := range tt.items {
+				targets[i] = item.FilterValue()
+			}
+			var got []int
+			for _, r := range filter(tt.term, targets) {
+				got = append(got, r.Index)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filter(%q) = %v, want %v", tt.term, got, tt.want)
+			}
+		})
+	}
+}
@@ -16,80 +16,175 @@ type Backup struct {
 	Name      string
 	Path      string
 	CreatedAt time.Time
+	ObjectID  string // root object in the store; empty for legacy file backups
+	Kind      string // KindBlob or KindTree
+	Codec     string // compression codec of the root object
+	Hash      string // SHA-256 recorded at creation time
+	Size      int64  // total size of the saved contents in bytes
+	Note      string
+	Tags      []string
+	DeletedAt time.Time // when the backup was moved to the trash; zero if it is live
+	GameID    string    // game profile the backup belongs to
+}
+
+// CreateOptions controls how a new backup is stored.
+type CreateOptions struct {
+	Compression Compression
+	Filter      Filter // files of a directory save to include
+	GameID      string // game profile the backup belongs to
 }
 
 // DB represents the backup database.
 type DB struct {
 	*sql.DB
+	dir   string
+	store *Store
 }
 
-// InitDB initializes the database in the backup directory.
-func InitDB(backupDir string) (*DB, error) {
+// InitDB initializes the database in the backup directory, upgrading the
+// schema of databases created by older versions. Backups made before game
+// profiles existed are given to legacyGameID during that upgrade.
+func InitDB(backupDir, legacyGameID string) (*DB, error) {
 	if err := os.MkdirAll(backupDir, 0755); err != nil {
 		return nil, err
 	}
-	dbPath := filepath.Join(backupDir, "backups.db")
+	dbPath := filepath.Join(backupDir, dbFileName)
 	db, err := sql.Open("sqlite3", dbPath)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create table if not exists
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS backups (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT NOT NULL,
-			path TEXT NOT NULL,
-			created_at DATETIME NOT NULL
-		)
-	`)
-	if err != nil {
+	if err := migrate(db, dbPath, legacyGameID); err != nil {
+		db.Close()
 		return nil, err
 	}
 
-	return &DB{db}, nil
+	return &DB{DB: db, dir: backupDir, store: NewStore(backupDir)}, nil
 }
 
-// CreateBackup creates a new backup.
-func (db *DB) CreateBackup(savePath, backupDir, backupName string) error {
+// Store returns the object store holding the backup contents.
+func (db *DB) Store() *Store {
+	return db.store
+}
+
+// CreateBackup creates a new backup. The save path may be a single file or a
+// directory, in which case the whole tree is stored. Contents are kept in the
+// object store, so unchanged files are not stored again. It returns the new
+// backup, whose name may have been made unique.
+func (db *DB) CreateBackup(savePath, backupName string, opts CreateOptions) (Backup, error) {
 	if _, err := os.Stat(savePath); os.IsNotExist(err) {
-		return fmt.Errorf("save file not found: %s", savePath)
+		return Backup{}, fmt.Errorf("save file not found: %s", savePath)
+	} else if err != nil {
+		return Backup{}, err
 	}
 
 	if backupName == "" {
 		backupName = fmt.Sprintf("Backup_%s", time.Now().Format("2006-01-02_15-04-05"))
 	}
 
-	backupPath := filepath.Join(backupDir, backupName+".sav")
 	// Ensure the backup name is unique
+	backupName, err := db.uniqueName(opts.GameID, backupName)
+	if err != nil {
+		return Backup{}, err
+	}
+
+	if err := opts.Filter.Validate(); err != nil {
+		return Backup{}, err
+	}
+
+	snap, err := db.store.snapshot(savePath, opts.Compression, opts.Filter)
+	if err != nil {
+		return Backup{}, err
+	}
+
+	id, err := db.recordSnapshot(opts.GameID, backupName, snap, time.Now())
+	if err != nil {
+		db.store.discard(snap.created)
+		return Backup{}, err
+	}
+	return db.getBackup(id)
+}
+
+// uniqueName returns name, or name with a numeric suffix if the game already
+// has a backup with that name.
+func (db *DB) uniqueName(gameID, name string) (string, error) {
 	counter := 1
-	baseName := backupName
+	baseName := name
 	for {
-		if _, err := os.Stat(backupPath); os.IsNotExist(err) {
-			break
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM backups WHERE game_id = ? AND name = ?", gameID, name).Scan(&count); err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return name, nil
 		}
-		backupName = fmt.Sprintf("%s_%d", baseName, counter)
-		backupPath = filepath.Join(backupDir, backupName+".sav")
+		name = fmt.Sprintf("%s_%d", baseName, counter)
 		counter++
 	}
+}
 
-	data, err := os.ReadFile(savePath)
+// recordSnapshot adds a backup row and takes a reference on every object it
+// uses. It returns the ID of the new backup.
+func (db *DB) recordSnapshot(gameID, name string, snap *snapshot, createdAt time.Time) (int, error) {
+	tx, err := db.Begin()
 	if err != nil {
-		return err
+		return 0, err
 	}
+	defer tx.Rollback()
 
-	if err := os.WriteFile(backupPath, data, 0644); err != nil {
-		return err
+	for id, obj := range snap.objects {
+		kind := KindBlob
+		if id == snap.root.ID {
+			kind = snap.kind
+		}
+		_, err := tx.Exec(`
+			INSERT INTO objects (id, kind, size, refcount, codec) VALUES (?, ?, ?, 1, ?)
+			ON CONFLICT(id) DO UPDATE SET refcount = refcount + 1
+		`, id, kind, obj.Size, obj.Codec)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// The root object ID is the SHA-256 of the save (or of its tree manifest,
+	// which in turn lists the SHA-256 of every file)
+	res, err := tx.Exec("INSERT INTO backups (name, path, created_at, object_id, kind, codec, hash, size, game_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		name, db.store.ObjectPath(snap.root.ID), createdAt, snap.root.ID, snap.kind, snap.root.Codec, snap.root.ID, snap.size, gameID)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), tx.Commit()
+}
+
+// GetBackups retrieves a game's backups from the database, excluding those in the trash.
+func (db *DB) GetBackups(gameID string) ([]Backup, error) {
+	return db.queryBackups("WHERE game_id = ? AND deleted_at IS NULL ORDER BY created_at DESC", gameID)
+}
+
+// getBackup retrieves the backup with the given ID.
+func (db *DB) getBackup(id int) (Backup, error) {
+	backups, err := db.queryBackups("WHERE id = ?", id)
+	if err != nil {
+		return Backup{}, err
 	}
+	if len(backups) == 0 {
+		return Backup{}, fmt.Errorf("backup %d not found", id)
+	}
+	return backups[0], nil
+}
 
-	// Add to database
-	_, err = db.Exec("INSERT INTO backups (name, path, created_at) VALUES (?, ?, ?)", backupName, backupPath, time.Now())
-	return err
+// allBackups retrieves every backup, including those in the trash.
+func (db *DB) allBackups() ([]Backup, error) {
+	return db.queryBackups("ORDER BY created_at DESC")
 }
 
-// GetBackups retrieves all backups from the database.
-func (db *DB) GetBackups() ([]Backup, error) {
-	rows, err := db.Query("SELECT id, name, path, created_at FROM backups ORDER BY created_at DESC")
+// queryBackups loads backups matching the given WHERE/ORDER BY clause.
+func (db *DB) queryBackups(clause string, args ...interface{}) ([]Backup, error) {
+	rows, err := db.Query("SELECT id, name, path, created_at, object_id, kind, codec, hash, size, note, deleted_at, game_id FROM backups "+clause, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -98,53 +193,185 @@ func (db *DB) GetBackups() ([]Backup, error) {
 	var backups []Backup
 	for rows.Next() {
 		var b Backup
-		if err := rows.Scan(&b.ID, &b.Name, &b.Path, &b.CreatedAt); err != nil {
+		var objectID, kind, codec, hash sql.NullString
+		var size sql.NullInt64
+		var deletedAt sql.NullTime
+		if err := rows.Scan(&b.ID, &b.Name, &b.Path, &b.CreatedAt, &objectID, &kind, &codec, &hash, &size, &b.Note, &deletedAt, &b.GameID); err != nil {
 			return nil, err
 		}
+		b.ObjectID, b.Kind, b.Codec = objectID.String, kind.String, codec.String
+		b.Hash, b.Size = hash.String, size.Int64
+		b.DeletedAt = deletedAt.Time
+		if b.Codec == "" {
+			// Backups written before compression support are stored raw
+			b.Codec = CodecNone
+		}
 		backups = append(backups, b)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := db.loadTags(backups); err != nil {
+		return nil, err
+	}
 	return backups, nil
 }
 
-// RestoreBackup restores a selected backup.
+// RestoreBackup restores a selected backup. The restore is all-or-nothing:
+// the new contents are written next to the save and renamed into place, so a
+// failure part way through leaves the original save untouched. Directory
+// backups replace the whole save directory, removing files that were not in
+// the snapshot. If the backup was taken with a filter, live files outside the
+// filter are kept.
 func (db *DB) RestoreBackup(b Backup, savePath string) error {
-	data, err := os.ReadFile(b.Path)
+	if b.ObjectID == "" {
+		return restoreLegacy(b, savePath)
+	}
+
+	if err := checkRestoreTarget(b.Kind == KindTree, savePath); err != nil {
+		return err
+	}
+
+	if b.Kind == KindTree {
+		tree, err := db.store.ReadTree(b.ObjectID)
+		if err != nil {
+			return err
+		}
+		return atomicReplaceDir(savePath, func(tmp string) error {
+			if err := db.store.extractTree(tree, tmp); err != nil {
+				return err
+			}
+			if tree.Filter != nil {
+				return carryOver(savePath, tmp, *tree.Filter)
+			}
+			return nil
+		})
+	}
+
+	r, err := db.store.Open(b.ObjectID)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(savePath, data, 0644)
+	defer r.Close()
+	return atomicWriteFile(savePath, r)
 }
 
-// DeleteBackup deletes a backup.
-func (db *DB) DeleteBackup(b Backup) error {
-	if err := os.Remove(b.Path); err != nil {
+// restoreLegacy restores a backup created before the object store existed,
+// which is a plain copy of the save at b.Path.
+func restoreLegacy(b Backup, savePath string) error {
+	info, err := os.Stat(b.Path)
+	if err != nil {
+		return err
+	}
+	if err := checkRestoreTarget(info.IsDir(), savePath); err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return atomicReplaceDir(savePath, func(tmp string) error {
+			return copyTree(b.Path, tmp)
+		})
+	}
+
+	f, err := os.Open(b.Path)
+	if err != nil {
 		return err
 	}
-	_, err := db.Exec("DELETE FROM backups WHERE id = ?", b.ID)
-	return err
+	defer f.Close()
+	return atomicWriteFile(savePath, f)
+}
+
+// DeleteBackup moves a backup to the trash.
+func (db *DB) DeleteBackup(b Backup) error {
+	return db.DeleteBackups([]Backup{b})
 }
-// DeleteBackups deletes multiple backups in a single transaction.
-func (db *DB) DeleteBackups(backups []Backup) error {
+
+// PurgeBackups permanently deletes multiple backups in a single transaction.
+// Objects in the store are only removed once no other backup references them.
+func (db *DB) PurgeBackups(backups []Backup) error {
 	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
+	var freed []string
 	for _, b := range backups {
-		// Delete the file
-		if err := os.Remove(b.Path); err != nil {
-			// Continue with other deletions even if one file fails
-			// This handles cases where the file might already be deleted
-			continue
+		if b.ObjectID != "" {
+			ids, err := db.releaseObjects(tx, b)
+			if err != nil {
+				return err
+			}
+			freed = append(freed, ids...)
+		} else {
+			// Delete the file (or directory tree for directory saves).
+			// A file that is already gone still has its row removed.
+			if err := os.RemoveAll(b.Path); err != nil {
+				// Continue with other deletions even if one file fails
+				continue
+			}
 		}
-		
+
 		// Delete from database
+		if _, err := tx.Exec("DELETE FROM backup_tags WHERE backup_id = ?", b.ID); err != nil {
+			return err
+		}
 		_, err := tx.Exec("DELETE FROM backups WHERE id = ?", b.ID)
 		if err != nil {
 			return err
 		}
 	}
 
-	return tx.Commit()
-}
\ No newline at end of file
+	if _, err := tx.Exec("DELETE FROM tags WHERE id NOT IN (SELECT tag_id FROM backup_tags)"); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// Only remove object files once nothing in the database points at them
+	for _, id := range freed {
+		if err := db.store.Remove(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseObjects drops the references a backup holds and returns the IDs of
+// objects that are no longer used by any backup.
+func (db *DB) releaseObjects(tx *sql.Tx, b Backup) ([]string, error) {
+	ids := []string{b.ObjectID}
+	if b.Kind == KindTree {
+		// If the tree itself is gone its blobs cannot be found; they stay
+		// referenced rather than risking removal of shared content.
+		if tree, err := db.store.ReadTree(b.ObjectID); err == nil {
+			ids = append(ids, tree.Objects()...)
+		}
+	}
+
+	var freed []string
+	for _, id := range ids {
+		if _, err := tx.Exec("UPDATE objects SET refcount = refcount - 1 WHERE id = ?", id); err != nil {
+			return nil, err
+		}
+		var refcount int
+		err := tx.QueryRow("SELECT refcount FROM objects WHERE id = ?", id).Scan(&refcount)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if refcount <= 0 {
+			if _, err := tx.Exec("DELETE FROM objects WHERE id = ?", id); err != nil {
+				return nil, err
+			}
+			freed = append(freed, id)
+		}
+	}
+	return freed, nil
+}
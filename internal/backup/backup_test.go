@@ -0,0 +1,48 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCreateBackupReturnsNewBackup(t *testing.T) {
+	db := testDB(t)
+
+	// An imported backup dated in the future sorts before anything created now
+	objects := make(map[string][]byte)
+	future := testTreeBackup(t, "from the future", []TreeEntry{{Path: "a.sav", Mode: 0644}}, objects)
+	future.CreatedAt = time.Now().Add(24 * time.Hour)
+	if _, err := importTestBundle(db, testBundle(t, []BundleBackup{future}, objects)); err != nil {
+		t.Fatal(err)
+	}
+
+	save := filepath.Join(t.TempDir(), "profile.sav")
+	if err := os.WriteFile(save, []byte("save"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	first, err := db.CreateBackup(save, "boss", CreateOptions{GameID: "game"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := db.CreateBackup(save, "boss", CreateOptions{GameID: "game"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.Name != "boss" || second.Name != "boss_1" {
+		t.Errorf("created backups named %q and %q, want %q and %q", first.Name, second.Name, "boss", "boss_1")
+	}
+	if first.ID == second.ID || first.GameID != "game" || first.Hash == "" {
+		t.Errorf("created backups %+v and %+v are not distinct, complete records", first, second)
+	}
+
+	backups, err := db.GetBackups("game")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 3 || backups[0].Name != "from the future" {
+		t.Fatalf("got backups %+v, want the imported one first", backups)
+	}
+}
@@ -0,0 +1,543 @@
+package backup
+
+import (
+	"archive/tar"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// A bundle is a tar archive for moving backups between machines. It starts
+// with manifest.json, which describes every backup, followed by the
+// uncompressed contents of the objects they use as objects/<sha256>. A tree
+// object is the same JSON manifest the store keeps for a directory save, so
+// a bundle can be unpacked and read without this application.
+const (
+	BundleFormat  = "game-save-backup-manager/bundle"
+	BundleVersion = 1
+
+	bundleManifest = "manifest.json"
+	bundleObjects  = "objects/"
+)
+
+// BundleManifest is the manifest.json of a bundle.
+type BundleManifest struct {
+	Format    string         `json:"format"`
+	Version   int            `json:"version"`
+	CreatedAt time.Time      `json:"created_at"`
+	Backups   []BundleBackup `json:"backups"`
+}
+
+// BundleGame identifies the game a bundled backup belongs to.
+type BundleGame struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// BundleBackup describes one backup in a bundle.
+type BundleBackup struct {
+	Name      string     `json:"name"`
+	Game      BundleGame `json:"game"`
+	CreatedAt time.Time  `json:"created_at"`
+	Kind      string     `json:"kind"` // KindBlob or KindTree
+	Hash      string     `json:"hash"` // SHA-256 of the save file or tree manifest
+	Size      int64      `json:"size"` // total size of the saved contents
+	Note      string     `json:"note,omitempty"`
+	Tags      []string   `json:"tags,omitempty"`
+	// Objects lists every object the backup needs, starting with Hash.
+	Objects []string `json:"objects"`
+}
+
+// BundleName returns the default file name for a bundle of a game's backups.
+func BundleName(gameID string, t time.Time) string {
+	return fmt.Sprintf("%s-backups-%s.tar", gameID, t.Format("20060102-150405"))
+}
+
+// bundleSource is an object to write into a bundle.
+type bundleSource struct {
+	open func() (io.ReadCloser, error)
+	size int64
+}
+
+// ExportBundle writes backups to w as a bundle. gameNames gives the name of
+// each game ID, so the backups can be matched to a game on import. Every
+// object is checked against its checksum first, so a damaged backup is
+// reported rather than exported.
+func (db *DB) ExportBundle(w io.Writer, backups []Backup, gameNames map[string]string) error {
+	manifest := BundleManifest{Format: BundleFormat, Version: BundleVersion, CreatedAt: time.Now()}
+	sources := make(map[string]bundleSource)
+	var order []string
+	add := func(id string, src bundleSource) {
+		if _, ok := sources[id]; !ok {
+			sources[id] = src
+			order = append(order, id)
+		}
+	}
+
+	for _, b := range backups {
+		entry := BundleBackup{
+			Name:      b.Name,
+			Game:      BundleGame{ID: b.GameID, Name: gameNames[b.GameID]},
+			CreatedAt: b.CreatedAt,
+			Kind:      b.Kind,
+			Hash:      b.Hash,
+			Size:      b.Size,
+			Note:      b.Note,
+			Tags:      b.Tags,
+		}
+
+		if b.ObjectID == "" {
+			// Backups from before the object store are plain copies of the save
+			info, err := os.Stat(b.Path)
+			if err != nil {
+				return fmt.Errorf("backup %q: %v", b.Name, err)
+			}
+			if info.IsDir() {
+				return fmt.Errorf("backup %q is a directory copy from an older version and cannot be exported", b.Name)
+			}
+			sum, size, err := HashFile(b.Path)
+			if err != nil {
+				return fmt.Errorf("backup %q: %v", b.Name, err)
+			}
+			if b.Hash != "" && sum != b.Hash {
+				return fmt.Errorf("backup %q does not match its checksum", b.Name)
+			}
+			legacyPath := b.Path
+			entry.Kind, entry.Hash, entry.Size = KindBlob, sum, size
+			entry.Objects = []string{sum}
+			add(sum, bundleSource{size: size, open: func() (io.ReadCloser, error) { return os.Open(legacyPath) }})
+			manifest.Backups = append(manifest.Backups, entry)
+			continue
+		}
+
+		if b.Hash != "" && b.Hash != b.ObjectID {
+			return fmt.Errorf("backup %q does not match its checksum", b.Name)
+		}
+		entry.Hash = b.ObjectID
+		if entry.Kind == "" {
+			entry.Kind = KindBlob
+		}
+		entry.Objects = []string{b.ObjectID}
+		if b.Kind == KindTree {
+			tree, err := db.store.ReadTree(b.ObjectID)
+			if err != nil {
+				return fmt.Errorf("backup %q: %v", b.Name, err)
+			}
+			entry.Objects = append(entry.Objects, tree.Objects()...)
+		}
+		for _, id := range entry.Objects {
+			if _, ok := sources[id]; ok {
+				continue
+			}
+			size, _, err := db.store.verifyObject(id)
+			if err != nil {
+				return fmt.Errorf("backup %q: %v", b.Name, err)
+			}
+			objectID := id
+			add(id, bundleSource{size: size, open: func() (io.ReadCloser, error) { return db.store.Open(objectID) }})
+		}
+		manifest.Backups = append(manifest.Backups, entry)
+	}
+
+	tw := tar.NewWriter(w)
+	data, err := json.MarshalIndent(&manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeTarFile(tw, bundleManifest, int64(len(data)), strings.NewReader(string(data))); err != nil {
+		return err
+	}
+	for _, id := range order {
+		src := sources[id]
+		r, err := src.open()
+		if err != nil {
+			return err
+		}
+		err = writeTarFile(tw, bundleObjects+id, src.size, r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// writeTarFile adds a regular file to a tar archive.
+func writeTarFile(tw *tar.Writer, name string, size int64, r io.Reader) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    size,
+		ModTime: time.Now(),
+		Format:  tar.FormatPAX,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if _, err := io.Copy(tw, r); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
+
+// Ways of handling a bundled backup whose name is already taken.
+const (
+	CollisionRename = "rename" // import it with a numbered suffix
+	CollisionSkip   = "skip"   // leave it out
+)
+
+// ImportOptions controls how a bundle is imported.
+type ImportOptions struct {
+	// GameFor returns the ID of the game to import a bundled game's backups
+	// into, or "" to leave them out.
+	GameFor func(g BundleGame) string
+	// Compression returns how to store new objects for a game.
+	Compression func(gameID string) Compression
+	// OnCollision is CollisionRename or CollisionSkip.
+	OnCollision string
+}
+
+// ImportStatus is what happened to one bundled backup.
+type ImportStatus int
+
+const (
+	ImportAdded     ImportStatus = iota // imported under its own name
+	ImportRenamed                       // imported under a new name
+	ImportDuplicate                     // the game already has a backup with the same contents
+	ImportNameTaken                     // the name is taken and CollisionSkip was given
+	ImportNoGame                        // no game to import it into
+)
+
+// String returns a human-readable name for the status.
+func (s ImportStatus) String() string {
+	switch s {
+	case ImportAdded:
+		return "imported"
+	case ImportRenamed:
+		return "imported with a new name"
+	case ImportDuplicate:
+		return "already present"
+	case ImportNameTaken:
+		return "skipped, name taken"
+	case ImportNoGame:
+		return "skipped, no matching game"
+	}
+	return "unknown"
+}
+
+// ImportResult reports what happened to one bundled backup.
+type ImportResult struct {
+	Backup BundleBackup
+	Status ImportStatus
+	GameID string // game it was imported into
+	Name   string // name it was imported under
+}
+
+// Imported reports whether the backup was added.
+func (r ImportResult) Imported() bool {
+	return r.Status == ImportAdded || r.Status == ImportRenamed
+}
+
+// ImportBundle reads a bundle from r and adds its backups. A backup whose
+// game already has one with the same hash is not imported again. Objects
+// are checked against their checksums as they are read, so a damaged bundle
+// is refused before any backup is recorded. If recording fails partway, the
+// new objects no recorded backup uses are removed again.
+func (db *DB) ImportBundle(r io.Reader, opts ImportOptions) ([]ImportResult, error) {
+	if opts.OnCollision == "" {
+		opts.OnCollision = CollisionRename
+	}
+	if opts.OnCollision != CollisionRename && opts.OnCollision != CollisionSkip {
+		return nil, fmt.Errorf("unknown collision handling %q", opts.OnCollision)
+	}
+
+	tr := tar.NewReader(r)
+	manifest, err := readBundleManifest(tr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decide what to import before reading any object
+	results := make([]ImportResult, len(manifest.Backups))
+	needed := make(map[string]string) // object ID -> game it is stored for
+	hashes := make(map[string]map[string]bool)
+	names := make(map[string]map[string]bool)
+	for i, b := range manifest.Backups {
+		result := ImportResult{Backup: b, Name: b.Name}
+		if err := checkBundleBackup(b); err != nil {
+			return nil, err
+		}
+
+		result.GameID = opts.GameFor(b.Game)
+		if result.GameID == "" {
+			result.Status = ImportNoGame
+			results[i] = result
+			continue
+		}
+		if hashes[result.GameID] == nil {
+			if hashes[result.GameID], names[result.GameID], err = db.gameContents(result.GameID); err != nil {
+				return nil, err
+			}
+		}
+
+		switch {
+		case hashes[result.GameID][b.Hash]:
+			result.Status = ImportDuplicate
+		case names[result.GameID][b.Name] && opts.OnCollision == CollisionSkip:
+			result.Status = ImportNameTaken
+		default:
+			if names[result.GameID][b.Name] {
+				result.Status = ImportRenamed
+			}
+			hashes[result.GameID][b.Hash] = true
+			names[result.GameID][b.Name] = true
+			for _, id := range b.Objects {
+				if _, ok := needed[id]; !ok {
+					needed[id] = result.GameID
+				}
+			}
+		}
+		results[i] = result
+	}
+
+	objects, created, err := db.readBundleObjects(tr, needed, opts.Compression)
+	if err != nil {
+		db.store.discard(created)
+		return nil, err
+	}
+	for id := range needed {
+		if _, ok := objects[id]; !ok {
+			db.store.discard(created)
+			return nil, fmt.Errorf("bundle is missing object %s", short(id))
+		}
+	}
+
+	// Check every backup before recording any, so a bad one leaves nothing behind
+	snaps := make([]*snapshot, len(results))
+	for i, result := range results {
+		if !result.Imported() {
+			continue
+		}
+		if snaps[i], err = db.bundleSnapshot(result.Backup, objects); err != nil {
+			db.store.discard(created)
+			return nil, err
+		}
+	}
+
+	for i := range results {
+		result := &results[i]
+		if !result.Imported() {
+			continue
+		}
+		if err := db.importBackup(result, snaps[i]); err != nil {
+			db.discardUnreferenced(created)
+			return results[:i], err
+		}
+	}
+	return results, nil
+}
+
+// discardUnreferenced removes the objects in ids that no recorded backup
+// took a reference on, after an import stopped partway.
+func (db *DB) discardUnreferenced(ids []string) {
+	for _, id := range ids {
+		var refs int
+		err := db.QueryRow("SELECT COUNT(*) FROM objects WHERE id = ?", id).Scan(&refs)
+		if err == nil && refs == 0 {
+			db.store.Remove(id)
+		}
+	}
+}
+
+// readBundleManifest reads and checks the manifest at the start of a bundle.
+func readBundleManifest(tr *tar.Reader) (*BundleManifest, error) {
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("not a backup bundle: %v", err)
+	}
+	if hdr.Name != bundleManifest {
+		return nil, fmt.Errorf("not a backup bundle: it does not start with %s", bundleManifest)
+	}
+
+	var manifest BundleManifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid bundle manifest: %v", err)
+	}
+	if manifest.Format != BundleFormat {
+		return nil, fmt.Errorf("not a backup bundle: format is %q", manifest.Format)
+	}
+	if manifest.Version > BundleVersion {
+		return nil, fmt.Errorf("bundle format version %d is newer than this application supports (%d); please upgrade",
+			manifest.Version, BundleVersion)
+	}
+	return &manifest, nil
+}
+
+// checkBundleBackup checks that a manifest entry is usable.
+func checkBundleBackup(b BundleBackup) error {
+	if b.Name == "" {
+		return fmt.Errorf("invalid bundle manifest: a backup has no name")
+	}
+	if b.Kind != KindBlob && b.Kind != KindTree {
+		return fmt.Errorf("invalid bundle manifest: backup %q has unknown kind %q", b.Name, b.Kind)
+	}
+	if len(b.Objects) == 0 || b.Objects[0] != b.Hash {
+		return fmt.Errorf("invalid bundle manifest: backup %q does not list its own object first", b.Name)
+	}
+	for _, id := range b.Objects {
+		if !isObjectID(id) {
+			return fmt.Errorf("invalid bundle manifest: backup %q lists invalid object %q", b.Name, id)
+		}
+	}
+	return nil
+}
+
+// isObjectID reports whether id is a hex-encoded SHA-256.
+func isObjectID(id string) bool {
+	if len(id) != 64 {
+		return false
+	}
+	for _, r := range id {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// gameContents returns the hashes and names of a game's backups, including
+// those in the trash, whose names are still taken.
+func (db *DB) gameContents(gameID string) (hashes, names map[string]bool, err error) {
+	rows, err := db.Query("SELECT name, COALESCE(hash, object_id), deleted_at IS NULL FROM backups WHERE game_id = ?", gameID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	hashes, names = make(map[string]bool), make(map[string]bool)
+	for rows.Next() {
+		var name string
+		var hash sql.NullString
+		var live bool
+		if err := rows.Scan(&name, &hash, &live); err != nil {
+			return nil, nil, err
+		}
+		names[name] = true
+		if live && hash.String != "" {
+			hashes[hash.String] = true
+		}
+	}
+	return hashes, names, rows.Err()
+}
+
+// readBundleObjects stores the objects of a bundle that are needed, checking
+// each against its ID. It returns what was stored and which objects are new.
+func (db *DB) readBundleObjects(tr *tar.Reader, needed map[string]string, compression func(string) Compression) (map[string]ObjectInfo, []string, error) {
+	objects := make(map[string]ObjectInfo)
+	var created []string
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return objects, created, nil
+		}
+		if err != nil {
+			return objects, created, fmt.Errorf("reading bundle: %v", err)
+		}
+
+		id := strings.TrimPrefix(path.Clean(hdr.Name), bundleObjects)
+		gameID, ok := needed[id]
+		if !ok || hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		var c Compression
+		if compression != nil {
+			c = compression(gameID)
+		}
+		info, isNew, err := db.store.Put(tr, c)
+		if err != nil {
+			return objects, created, err
+		}
+		if isNew {
+			created = append(created, info.ID)
+		}
+		if info.ID != id {
+			return objects, created, fmt.Errorf("bundle object %s does not match its checksum", short(id))
+		}
+		objects[id] = info
+	}
+}
+
+// bundleSnapshot checks a bundled backup whose objects are in the store and
+// returns the snapshot to record for it.
+func (db *DB) bundleSnapshot(b BundleBackup, objects map[string]ObjectInfo) (*snapshot, error) {
+	snap := &snapshot{root: objects[b.Hash], kind: b.Kind, objects: make(map[string]ObjectInfo)}
+	snap.objects[b.Hash] = objects[b.Hash]
+	if b.Kind == KindBlob {
+		snap.size = objects[b.Hash].Size
+	} else {
+		tree, err := db.store.ReadTree(b.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("backup %q: %v", b.Name, err)
+		}
+		if err := tree.Validate(); err != nil {
+			return nil, fmt.Errorf("backup %q: %v", b.Name, err)
+		}
+
+		// The tree may only use objects listed for this backup; those of
+		// other backups in the bundle would be left without a reference
+		listed := make(map[string]bool, len(b.Objects))
+		for _, id := range b.Objects {
+			listed[id] = true
+		}
+		for _, id := range tree.Objects() {
+			if _, ok := objects[id]; !ok || !listed[id] {
+				return nil, fmt.Errorf("backup %q: bundle is missing object %s", b.Name, short(id))
+			}
+			snap.objects[id] = objects[id]
+		}
+
+		// The size is that of the files as stored, not what the manifest says
+		for _, e := range tree.Entries {
+			if e.Object != "" {
+				snap.size += objects[e.Object].Size
+			}
+		}
+	}
+	return snap, nil
+}
+
+// importBackup records one checked bundled backup.
+func (db *DB) importBackup(result *ImportResult, snap *snapshot) error {
+	b := result.Backup
+
+	name, err := db.uniqueName(result.GameID, b.Name)
+	if err != nil {
+		return err
+	}
+	id, err := db.recordSnapshot(result.GameID, name, snap, b.CreatedAt)
+	if err != nil {
+		return err
+	}
+	result.Name = name
+	if name != b.Name {
+		result.Status = ImportRenamed
+	}
+
+	if b.Note != "" {
+		if err := db.SetNote(id, b.Note); err != nil {
+			return err
+		}
+	}
+	if len(b.Tags) > 0 {
+		if err := db.SetTags(id, b.Tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
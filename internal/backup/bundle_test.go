@@ -0,0 +1,231 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testBundle builds a bundle holding backups and the given objects.
+func testBundle(t *testing.T, backups []BundleBackup, objects map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	manifest, err := json.Marshal(BundleManifest{Format: BundleFormat, Version: BundleVersion, Backups: backups})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeTarFile(tw, bundleManifest, int64(len(manifest)), bytes.NewReader(manifest)); err != nil {
+		t.Fatal(err)
+	}
+	for id, data := range objects {
+		if err := writeTarFile(tw, bundleObjects+id, int64(len(data)), bytes.NewReader(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// testObject returns the ID of data and adds it to objects.
+func testObject(objects map[string][]byte, data []byte) string {
+	sum := sha256.Sum256(data)
+	id := hex.EncodeToString(sum[:])
+	objects[id] = data
+	return id
+}
+
+// testTreeBackup returns a bundled tree backup with the given entries, adding
+// its objects to objects. Regular file entries get a blob each.
+func testTreeBackup(t *testing.T, name string, entries []TreeEntry, objects map[string][]byte) BundleBackup {
+	t.Helper()
+	var blobs []string
+	for i := range entries {
+		if entries[i].Mode.IsRegular() {
+			entries[i].Object = testObject(objects, []byte(name+entries[i].Path))
+			blobs = append(blobs, entries[i].Object)
+		}
+	}
+	data, err := json.Marshal(&Tree{Entries: entries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := testObject(objects, data)
+	return BundleBackup{
+		Name:      name,
+		Game:      BundleGame{ID: "game"},
+		CreatedAt: time.Now(),
+		Kind:      KindTree,
+		Hash:      hash,
+		Objects:   append([]string{hash}, blobs...),
+	}
+}
+
+func testDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := InitDB(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func importTestBundle(db *DB, data []byte) ([]ImportResult, error) {
+	return db.ImportBundle(bytes.NewReader(data), ImportOptions{
+		GameFor: func(g BundleGame) string { return g.ID },
+	})
+}
+
+func TestImportBundleRejectsUnsafeTrees(t *testing.T) {
+	dir := fs.ModeDir | 0755
+	tests := []struct {
+		name    string
+		entries []TreeEntry
+		want    string
+	}{
+		{"parent directory", []TreeEntry{{Path: "../sub", Mode: dir}}, "outside the save directory"},
+		{"nested parent directory", []TreeEntry{{Path: "a/../../b.sav", Mode: 0644}}, "outside the save directory"},
+		{"absolute path", []TreeEntry{{Path: "/tmp/b.sav", Mode: 0644}}, "outside the save directory"},
+		{"empty path", []TreeEntry{{Path: "", Mode: dir}}, "outside the save directory"},
+		{"duplicate entry", []TreeEntry{{Path: "a.sav", Mode: 0644}, {Path: "a.sav", Mode: 0644}}, "more than once"},
+		{"entry under a symlink", []TreeEntry{
+			{Path: "link", Mode: fs.ModeSymlink | 0777, Link: "/tmp"},
+			{Path: "link/b.sav", Mode: 0644},
+		}, "inside the symbolic link"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDB(t)
+			objects := make(map[string][]byte)
+			b := testTreeBackup(t, "bad", tt.entries, objects)
+			_, err := importTestBundle(db, testBundle(t, []BundleBackup{b}, objects))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("ImportBundle() error = %v, want one containing %q", err, tt.want)
+			}
+			for id := range objects {
+				if db.store.Has(id) {
+					t.Errorf("object %s was left in the store", short(id))
+				}
+			}
+			if backups, _ := db.GetBackups("game"); len(backups) != 0 {
+				t.Errorf("got %d backup(s) recorded, want none", len(backups))
+			}
+		})
+	}
+}
+
+func TestImportBundleTreeUsesOnlyItsOwnObjects(t *testing.T) {
+	db := testDB(t)
+	objects := make(map[string][]byte)
+	first := testTreeBackup(t, "first", []TreeEntry{{Path: "a.sav", Mode: 0644}}, objects)
+
+	// The second tree uses the first backup's blob without listing it
+	shared := first.Objects[1]
+	data, _ := json.Marshal(&Tree{Entries: []TreeEntry{{Path: "b.sav", Mode: 0644, Object: shared}}})
+	hash := testObject(objects, data)
+	second := BundleBackup{Name: "second", Game: BundleGame{ID: "game"}, Kind: KindTree, Hash: hash, Objects: []string{hash}}
+
+	_, err := importTestBundle(db, testBundle(t, []BundleBackup{first, second}, objects))
+	if err == nil || !strings.Contains(err.Error(), "missing object") {
+		t.Fatalf("ImportBundle() error = %v, want a missing object error", err)
+	}
+}
+
+func TestImportBundleValidTree(t *testing.T) {
+	db := testDB(t)
+	objects := make(map[string][]byte)
+	b := testTreeBackup(t, "good", []TreeEntry{
+		{Path: "slot1", Mode: fs.ModeDir | 0755},
+		{Path: "slot1/a.sav", Mode: 0644},
+		{Path: "latest", Mode: fs.ModeSymlink | 0777, Link: "slot1/a.sav"},
+	}, objects)
+
+	results, err := importTestBundle(db, testBundle(t, []BundleBackup{b}, objects))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Status != ImportAdded {
+		t.Fatalf("got results %+v, want one added backup", results)
+	}
+	backups, err := db.GetBackups("game")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 || backups[0].Name != "good" {
+		t.Fatalf("got backups %+v, want one named good", backups)
+	}
+}
+
+func TestImportBundleSizesComeFromTheObjects(t *testing.T) {
+	db := testDB(t)
+	objects := make(map[string][]byte)
+	blob := testObject(objects, []byte("twelve bytes"))
+	tree := testTreeBackup(t, "tree", []TreeEntry{
+		{Path: "a.sav", Mode: 0644},
+		{Path: "b.sav", Mode: 0644},
+	}, objects)
+	tree.Size = 1
+	backups := []BundleBackup{
+		{Name: "blob", Game: BundleGame{ID: "game"}, Kind: KindBlob, Hash: blob, Objects: []string{blob}, Size: 999},
+		tree,
+	}
+	if _, err := importTestBundle(db, testBundle(t, backups, objects)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each tree file holds its backup's name and its path
+	want := map[string]int64{"blob": 12, "tree": int64(len("treea.sav") + len("treeb.sav"))}
+	recorded, err := db.GetBackups("game")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range recorded {
+		if b.Size != want[b.Name] {
+			t.Errorf("backup %q has size %d, want %d", b.Name, b.Size, want[b.Name])
+		}
+	}
+}
+
+func TestImportBundleDiscardsObjectsOfUnrecordedBackups(t *testing.T) {
+	db := testDB(t)
+	objects := make(map[string][]byte)
+	first := testTreeBackup(t, "first", []TreeEntry{{Path: "a.sav", Mode: 0644}}, objects)
+	second := testTreeBackup(t, "second", []TreeEntry{{Path: "a.sav", Mode: 0644}}, objects)
+
+	// Recording the second backup fails after the first is recorded
+	_, err := db.Exec(`CREATE TRIGGER fail_second BEFORE INSERT ON backups WHEN NEW.name = 'second'
+		BEGIN SELECT RAISE(ABORT, 'disk full'); END`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := importTestBundle(db, testBundle(t, []BundleBackup{first, second}, objects))
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("ImportBundle() error = %v, want the recording error", err)
+	}
+	if len(results) != 1 || results[0].Name != "first" {
+		t.Errorf("got results %+v, want only the first backup", results)
+	}
+	for _, id := range first.Objects {
+		if !db.store.Has(id) {
+			t.Errorf("object %s of the recorded backup was removed", short(id))
+		}
+	}
+	for _, id := range second.Objects {
+		if db.store.Has(id) {
+			t.Errorf("object %s of the unrecorded backup was left in the store", short(id))
+		}
+	}
+	if report, err := db.Reconcile("game", ""); err != nil || !report.Clean() {
+		t.Errorf("Reconcile() = %+v, %v; want the database and store to agree", report, err)
+	}
+}
@@ -0,0 +1,124 @@
+package backup
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Supported compression codecs for stored objects.
+const (
+	CodecNone = "none"
+	CodecGzip = "gzip"
+	CodecZstd = "zstd"
+)
+
+// codecs lists every codec in the order the store probes for objects,
+// along with the file extension used on disk.
+var codecs = []struct {
+	name string
+	ext  string
+}{
+	{CodecNone, ""},
+	{CodecGzip, ".gz"},
+	{CodecZstd, ".zst"},
+}
+
+// Compression selects how new objects are written to the store.
+type Compression struct {
+	Codec string // CodecNone, CodecGzip or CodecZstd; empty means CodecNone
+	Level int    // codec-specific level; 0 uses the codec default
+}
+
+// codecExt returns the file extension for a codec.
+func codecExt(codec string) (string, error) {
+	if codec == "" {
+		codec = CodecNone
+	}
+	for _, c := range codecs {
+		if c.name == codec {
+			return c.ext, nil
+		}
+	}
+	return "", fmt.Errorf("unknown compression codec: %s", codec)
+}
+
+// ValidateCompression checks that a codec and level are supported.
+func ValidateCompression(c Compression) error {
+	if _, err := codecExt(c.Codec); err != nil {
+		return err
+	}
+	switch c.Codec {
+	case CodecGzip:
+		if c.Level < 0 || c.Level > gzip.BestCompression {
+			return fmt.Errorf("gzip compression level must be between 1 and %d", gzip.BestCompression)
+		}
+	case CodecZstd:
+		if c.Level < 0 || c.Level > 22 {
+			return fmt.Errorf("zstd compression level must be between 1 and 22")
+		}
+	}
+	return nil
+}
+
+// nopWriteCloser adapts a writer that needs no flushing.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// newCompressor wraps w so that data written to it is compressed with c.
+// Closing the returned writer flushes it but does not close w.
+func newCompressor(w io.Writer, c Compression) (io.WriteCloser, error) {
+	if err := ValidateCompression(c); err != nil {
+		return nil, err
+	}
+
+	switch c.Codec {
+	case CodecGzip:
+		level := c.Level
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		return gzip.NewWriterLevel(w, level)
+	case CodecZstd:
+		level := zstd.SpeedDefault
+		if c.Level != 0 {
+			level = zstd.EncoderLevelFromZstd(c.Level)
+		}
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(level))
+	default:
+		return nopWriteCloser{w}, nil
+	}
+}
+
+// zstdReadCloser releases decoder resources on Close.
+type zstdReadCloser struct {
+	*zstd.Decoder
+}
+
+func (z zstdReadCloser) Close() error {
+	z.Decoder.Close()
+	return nil
+}
+
+// newDecompressor wraps r so that reads return the decompressed contents.
+// Closing the returned reader does not close r.
+func newDecompressor(r io.Reader, codec string) (io.ReadCloser, error) {
+	switch codec {
+	case CodecGzip:
+		return gzip.NewReader(r)
+	case CodecZstd:
+		d, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zstdReadCloser{d}, nil
+	case CodecNone, "":
+		return io.NopCloser(r), nil
+	}
+	return nil, fmt.Errorf("unknown compression codec: %s", codec)
+}
@@ -0,0 +1,95 @@
+package backup
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// copyFile copies a single regular file, preserving its mode and modification time.
+// The copy is flushed to disk before it is closed.
+func copyFile(src, dst string, mode fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+// copyTree recursively copies the directory src into dst.
+// Regular files, directories and symlinks are copied; other file types are skipped.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return copyFile(path, target, info.Mode())
+		}
+		return nil
+	})
+}
+
+// checkRestoreTarget ensures a backup can be restored over savePath without
+// mixing a file backup into a directory save or the other way round.
+func checkRestoreTarget(backupIsDir bool, savePath string) error {
+	info, err := os.Stat(savePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.IsDir() != backupIsDir {
+		if backupIsDir {
+			return fmt.Errorf("backup is a directory but save path is a file: %s", savePath)
+		}
+		return fmt.Errorf("backup is a file but save path is a directory: %s", savePath)
+	}
+	return nil
+}
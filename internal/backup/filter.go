@@ -0,0 +1,275 @@
+package backup
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// Filter selects which files of a directory save are backed up. Patterns
+// are doublestar globs matched against paths relative to the save directory,
+// using forward slashes. A file is selected if it, or a directory containing
+// it, matches an Include pattern (or there are none), and nothing on its path
+// matches an Exclude pattern.
+type Filter struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// IsEmpty returns true if the filter selects every file.
+func (f Filter) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// Validate checks that every pattern is a valid glob.
+func (f Filter) Validate() error {
+	for _, p := range append(append([]string{}, f.Include...), f.Exclude...) {
+		if !doublestar.ValidatePattern(p) {
+			return fmt.Errorf("invalid glob pattern: %q", p)
+		}
+	}
+	return nil
+}
+
+// Selects reports whether the file at rel is part of the backup.
+func (f Filter) Selects(rel string) bool {
+	included := len(f.Include) == 0
+	for p := rel; p != "." && p != "/" && p != ""; p = path.Dir(p) {
+		if matchAny(f.Exclude, p) {
+			return false
+		}
+		if !included && matchAny(f.Include, p) {
+			included = true
+		}
+	}
+	return included
+}
+
+// excludesDir reports whether a whole directory is left out of the backup.
+func (f Filter) excludesDir(rel string) bool {
+	return matchAny(f.Exclude, rel)
+}
+
+// matchAny reports whether name matches any of the patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := doublestar.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// walkSave calls fn for every entry below root that f keeps. Excluded
+// directories are skipped without being read.
+func walkSave(root string, f Filter, fn func(path, rel string, d fs.DirEntry) error) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if f.excludesDir(rel) {
+				return filepath.SkipDir
+			}
+		} else if !f.Selects(rel) {
+			return nil
+		}
+		return fn(p, rel, d)
+	})
+}
+
+// pruneDirs drops directory entries that contain nothing the filter selected.
+// Without include patterns every directory that was walked is kept, so empty
+// directories survive a backup as they did before filters existed.
+func pruneDirs(entries []TreeEntry, f Filter) []TreeEntry {
+	if len(f.Include) == 0 {
+		return entries
+	}
+
+	needed := make(map[string]bool)
+	for _, e := range entries {
+		if e.Mode.IsDir() {
+			continue
+		}
+		for p := path.Dir(e.Path); p != "."; p = path.Dir(p) {
+			needed[p] = true
+		}
+	}
+
+	kept := entries[:0]
+	for _, e := range entries {
+		if !e.Mode.IsDir() || needed[e.Path] {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// carryOver copies the entries of the live save at src that the filter does
+// not select into dst, so that restoring a filtered backup leaves caches,
+// logs and other excluded files where they were. Files are hard-linked where
+// possible to avoid copying large caches.
+func carryOver(src, dst string, f Filter) error {
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == src {
+			return nil
+		}
+
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if d.IsDir() {
+			if !f.excludesDir(filepath.ToSlash(rel)) {
+				return nil
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := linkTree(p, target); err != nil {
+				return err
+			}
+			return filepath.SkipDir
+		}
+
+		if f.Selects(filepath.ToSlash(rel)) {
+			return nil
+		}
+		if _, err := os.Lstat(target); err == nil {
+			// The backup has its own copy
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return linkEntry(p, target, d)
+	})
+}
+
+// linkTree recreates the directory src at dst, hard-linking its files.
+func linkTree(src, dst string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		}
+		return linkEntry(p, target, d)
+	})
+}
+
+// linkEntry hard-links a file from src to dst, falling back to a copy.
+// Symlinks are recreated and other file types are skipped.
+func linkEntry(src, dst string, d fs.DirEntry) error {
+	switch {
+	case d.Type()&fs.ModeSymlink != 0:
+		link, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(link, dst)
+	case d.Type().IsRegular():
+		if err := os.Link(src, dst); err == nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return copyFile(src, dst, info.Mode())
+	}
+	return nil
+}
+
+// PreviewFile is a file listed by PreviewFilter.
+type PreviewFile struct {
+	Path string // relative to the save directory, with forward slashes
+	Size int64
+}
+
+// FilterPreview lists which files of a save a filter would back up.
+type FilterPreview struct {
+	Included []PreviewFile
+	Excluded []PreviewFile
+}
+
+// PreviewFilter lists the files below savePath that f selects and those it
+// leaves out. A single-file save is always included in full.
+func PreviewFilter(savePath string, f Filter) (*FilterPreview, error) {
+	info, err := os.Stat(savePath)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &FilterPreview{}
+	if !info.IsDir() {
+		preview.Included = append(preview.Included, PreviewFile{Path: filepath.Base(savePath), Size: info.Size()})
+		return preview, nil
+	}
+
+	err = filepath.WalkDir(savePath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !(d.Type().IsRegular() || d.Type()&fs.ModeSymlink != 0) {
+			return nil
+		}
+		rel, err := filepath.Rel(savePath, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		file := PreviewFile{Path: filepath.ToSlash(rel), Size: info.Size()}
+		if f.Selects(file.Path) {
+			preview.Included = append(preview.Included, file)
+		} else {
+			preview.Excluded = append(preview.Excluded, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(preview.Included, func(i, j int) bool { return preview.Included[i].Path < preview.Included[j].Path })
+	sort.Slice(preview.Excluded, func(i, j int) bool { return preview.Excluded[i].Path < preview.Excluded[j].Path })
+	return preview, nil
+}
@@ -0,0 +1,132 @@
+package backup
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// dbFileName is the name of the database in a backup directory.
+const dbFileName = "backups.db"
+
+// DatabaseInfo describes the database of a backup directory as found on
+// disk, without opening it for use or upgrading it.
+type DatabaseInfo struct {
+	Path       string
+	Exists     bool
+	Version    int      // schema version recorded in the file; 0 before versioning
+	Problems   []string // what SQLite's integrity check reported; empty if none
+	Locked     bool     // another process is writing to the database
+	HotJournal bool     // a rollback journal was left behind by an interrupted write
+}
+
+// InspectDatabase reads the schema version of the database in backupDir and
+// runs SQLite's integrity check on it. The database is opened read-only, so
+// it is safe to call while the application has it open.
+func InspectDatabase(backupDir string) (*DatabaseInfo, error) {
+	info := &DatabaseInfo{Path: filepath.Join(backupDir, dbFileName)}
+	if _, err := os.Stat(info.Path); os.IsNotExist(err) {
+		return info, nil
+	} else if err != nil {
+		return nil, err
+	}
+	info.Exists = true
+
+	if journal, err := os.Stat(info.Path + "-journal"); err == nil && journal.Size() > 0 {
+		info.HotJournal = true
+	}
+
+	locked, err := isLocked(info.Path)
+	if err != nil {
+		return nil, err
+	}
+	info.Locked = locked
+
+	db, err := sql.Open("sqlite3", fileURI(info.Path, "mode=ro"))
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	if info.Version, err = currentVersion(db); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		// A row may hold several problems under a "*** in database ***" heading
+		for _, problem := range strings.Split(line, "\n") {
+			if problem != "ok" && !strings.HasPrefix(problem, "***") {
+				info.Problems = append(info.Problems, problem)
+			}
+		}
+	}
+	return info, rows.Err()
+}
+
+// isLocked reports whether another connection holds a write lock on the
+// database at path, by trying to take one without waiting.
+func isLocked(path string) (bool, error) {
+	db, err := sql.Open("sqlite3", fileURI(path, "mode=rw&_txlock=immediate&_busy_timeout=0"))
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+
+	// SQLITE_BUSY is matched by its message, as the driver's error type
+	// only exists in cgo builds
+	tx, err := db.Begin()
+	if err != nil && strings.Contains(err.Error(), "database is locked") {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: %v", path, err)
+	}
+	return false, tx.Rollback()
+}
+
+// fileURI returns an SQLite URI for the database at path with query
+// parameters, escaping the characters that would end the path early.
+func fileURI(path, query string) string {
+	path = strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(filepath.ToSlash(path))
+	return "file:" + path + "?" + query
+}
+
+// Leftovers returns the temporary files interrupted operations have left in
+// a backup directory: objects that were being stored when the application
+// stopped.
+func Leftovers(backupDir string) ([]string, error) {
+	return filepath.Glob(filepath.Join(NewStore(backupDir).root, ".incoming-*"))
+}
+
+// RestoreLeftovers returns what an interrupted restore has left next to a
+// save: the copy being written, and the previous save if putting it back
+// failed. The latter may hold the only copy of the save.
+func RestoreLeftovers(savePath string) ([]string, error) {
+	dir, base := filepath.Dir(savePath), filepath.Base(savePath)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var found []string
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasPrefix(name, "."+base+".restore-") || strings.HasPrefix(name, "."+base+".old-") {
+			found = append(found, filepath.Join(dir, name))
+		}
+	}
+	return found, nil
+}
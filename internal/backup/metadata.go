@@ -0,0 +1,120 @@
+package backup
+
+import (
+	"sort"
+	"strings"
+)
+
+// NormalizeTags trims tags, drops empty ones and removes case-insensitive duplicates.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]struct{})
+	var result []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		key := strings.ToLower(tag)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		result = append(result, tag)
+	}
+	return result
+}
+
+// ParseTags splits a comma-separated list of tags.
+func ParseTags(s string) []string {
+	return NormalizeTags(strings.Split(s, ","))
+}
+
+// SetNote replaces the free-text note of a backup.
+func (db *DB) SetNote(backupID int, note string) error {
+	_, err := db.Exec("UPDATE backups SET note = ? WHERE id = ?", strings.TrimSpace(note), backupID)
+	return err
+}
+
+// SetTags replaces the tags of a backup. Tags no longer used by any backup are removed.
+func (db *DB) SetTags(backupID int, tags []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM backup_tags WHERE backup_id = ?", backupID); err != nil {
+		return err
+	}
+
+	for _, tag := range NormalizeTags(tags) {
+		if _, err := tx.Exec("INSERT INTO tags (name) VALUES (?) ON CONFLICT(name) DO NOTHING", tag); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`
+			INSERT OR IGNORE INTO backup_tags (backup_id, tag_id)
+			SELECT ?, id FROM tags WHERE name = ?
+		`, backupID, tag)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM tags WHERE id NOT IN (SELECT tag_id FROM backup_tags)"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetTags returns every tag in use, sorted by name.
+func (db *DB) GetTags() ([]string, error) {
+	rows, err := db.Query("SELECT name FROM tags ORDER BY name COLLATE NOCASE")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// loadTags fills in the tags of the given backups.
+func (db *DB) loadTags(backups []Backup) error {
+	rows, err := db.Query(`
+		SELECT bt.backup_id, t.name
+		FROM backup_tags bt JOIN tags t ON t.id = bt.tag_id
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	byBackup := make(map[int][]string)
+	for rows.Next() {
+		var id int
+		var tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			return err
+		}
+		byBackup[id] = append(byBackup[id], tag)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range backups {
+		tags := byBackup[backups[i].ID]
+		sort.Slice(tags, func(a, b int) bool {
+			return strings.ToLower(tags[a]) < strings.ToLower(tags[b])
+		})
+		backups[i].Tags = tags
+	}
+	return nil
+}
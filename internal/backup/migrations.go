@@ -0,0 +1,240 @@
+package backup
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// migration upgrades the database schema by one version.
+type migration struct {
+	version     int
+	description string
+	up          func(tx *sql.Tx) error
+}
+
+// migrations lists every schema change in order. Databases created before
+// schema versioning existed may already contain some of these changes, so
+// each step must be safe to apply to a schema that already has it.
+var migrations = []migration{
+	{1, "create backups table", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS backups (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL,
+				path TEXT NOT NULL,
+				created_at DATETIME NOT NULL
+			)
+		`)
+		return err
+	}},
+	{2, "add content-addressed object store", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS objects (
+				id TEXT PRIMARY KEY,
+				kind TEXT NOT NULL,
+				size INTEGER NOT NULL,
+				refcount INTEGER NOT NULL DEFAULT 0
+			)
+		`)
+		if err != nil {
+			return err
+		}
+		if err := ensureColumn(tx, "backups", "object_id", "TEXT"); err != nil {
+			return err
+		}
+		return ensureColumn(tx, "backups", "kind", "TEXT")
+	}},
+	{3, "record compression codec", func(tx *sql.Tx) error {
+		if err := ensureColumn(tx, "backups", "codec", "TEXT"); err != nil {
+			return err
+		}
+		return ensureColumn(tx, "objects", "codec", "TEXT NOT NULL DEFAULT 'none'")
+	}},
+	{4, "record checksums and sizes", func(tx *sql.Tx) error {
+		if err := ensureColumn(tx, "backups", "hash", "TEXT"); err != nil {
+			return err
+		}
+		return ensureColumn(tx, "backups", "size", "INTEGER")
+	}},
+	{5, "add notes and tags", func(tx *sql.Tx) error {
+		if err := ensureColumn(tx, "backups", "note", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS tags (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL UNIQUE COLLATE NOCASE
+			);
+			CREATE TABLE IF NOT EXISTS backup_tags (
+				backup_id INTEGER NOT NULL REFERENCES backups(id),
+				tag_id INTEGER NOT NULL REFERENCES tags(id),
+				PRIMARY KEY (backup_id, tag_id)
+			)
+		`)
+		return err
+	}},
+	{6, "add trash", func(tx *sql.Tx) error {
+		return ensureColumn(tx, "backups", "deleted_at", "DATETIME")
+	}},
+	{7, "add game profiles", func(tx *sql.Tx) error {
+		return ensureColumn(tx, "backups", "game_id", "TEXT NOT NULL DEFAULT ''")
+	}},
+}
+
+// gameProfilesVersion added game_id. Backups recorded before it belong to
+// the game that replaced the single configured save path.
+const gameProfilesVersion = 7
+
+// SchemaVersion is the newest database schema this build understands.
+var SchemaVersion = migrations[len(migrations)-1].version
+
+// queryExecer is satisfied by both *sql.DB and *sql.Tx.
+type queryExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// ensureColumn adds a column to a table if it does not exist yet.
+func ensureColumn(db queryExecer, table, column, definition string) error {
+	exists, err := hasColumn(db, table, column)
+	if err != nil || exists {
+		return err
+	}
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
+// hasColumn reports whether a table has the named column.
+func hasColumn(db queryExecer, table, column string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// currentVersion returns the schema version recorded in the database, or 0
+// for databases created before versioning. It only reads, so a database can
+// be copied unchanged before it is upgraded.
+func currentVersion(db queryExecer) (int, error) {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_version'").Scan(&count); err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, nil
+	}
+	var version sql.NullInt64
+	if err := db.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&version); err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+// hasTables reports whether the database contains any application tables.
+func hasTables(db queryExecer) (bool, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'backups'").Scan(&count)
+	return count > 0, err
+}
+
+// migrate brings the database schema up to SchemaVersion. Existing databases
+// are copied to a backup file first, and all steps run in one transaction so
+// a failed upgrade leaves the database as it was. Upgrades from before game
+// profiles give the existing backups to legacyGameID.
+func migrate(db *sql.DB, dbPath, legacyGameID string) error {
+	version, err := currentVersion(db)
+	if err != nil {
+		return err
+	}
+	if version > SchemaVersion {
+		return fmt.Errorf("%s uses schema version %d, but this version of the application only supports up to version %d; please upgrade the application",
+			dbPath, version, SchemaVersion)
+	}
+	if version == SchemaVersion {
+		return nil
+	}
+
+	existing, err := hasTables(db)
+	if err != nil {
+		return err
+	}
+	if existing {
+		if err := copyDatabase(db, dbPath, version); err != nil {
+			return fmt.Errorf("failed to back up database before migrating: %v", err)
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		if err := m.up(tx); err != nil {
+			return fmt.Errorf("database migration %d (%s) failed: %v", m.version, m.description, err)
+		}
+	}
+	if version < gameProfilesVersion && legacyGameID != "" {
+		if _, err := tx.Exec("UPDATE backups SET game_id = ? WHERE game_id = ''", legacyGameID); err != nil {
+			return fmt.Errorf("failed to assign existing backups to %s: %v", legacyGameID, err)
+		}
+	}
+
+	if _, err := tx.Exec("CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM schema_version"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO schema_version (version) VALUES (?)", SchemaVersion); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// copyDatabase writes a copy of the database at schema version to a new
+// file next to it. The name is made unique, so upgrades within the same
+// second don't overwrite each other's copies.
+func copyDatabase(db *sql.DB, dbPath string, version int) error {
+	pattern := fmt.Sprintf("%s.v%d-%s-*.bak", filepath.Base(dbPath), version, time.Now().Format("20060102-150405"))
+	f, err := os.CreateTemp(filepath.Dir(dbPath), pattern)
+	if err != nil {
+		return err
+	}
+	backupPath := f.Name()
+	f.Close()
+
+	// VACUUM INTO accepts an empty file, so the reserved name can be used
+	if _, err := db.Exec("VACUUM INTO ?", backupPath); err != nil {
+		os.Remove(backupPath)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package backup
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// baselineSchema is the database the first release created, before schema
+// versioning existed.
+const baselineSchema = `
+	CREATE TABLE backups (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		path TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+	INSERT INTO backups (name, path, created_at) VALUES ('old', '/backups/old.sav', '2024-01-02 03:04:05');
+`
+
+// writeDB creates backups.db in dir with the given statements.
+func writeDB(t *testing.T, dir, statements string) string {
+	t.Helper()
+	path := filepath.Join(dir, dbFileName)
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(statements); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func schemaVersion(t *testing.T, db queryExecer) int {
+	t.Helper()
+	version, err := currentVersion(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return version
+}
+
+func TestMigrateFromBaseline(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{"baseline", baselineSchema},
+		// Builds from before versioning added some columns without recording it
+		{"partly upgraded", baselineSchema + `
+			ALTER TABLE backups ADD COLUMN object_id TEXT;
+			ALTER TABLE backups ADD COLUMN kind TEXT;
+			CREATE TABLE objects (id TEXT PRIMARY KEY, kind TEXT NOT NULL, size INTEGER NOT NULL, refcount INTEGER NOT NULL DEFAULT 0);
+		`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := writeDB(t, dir, tt.schema)
+
+			db, err := InitDB(dir, "")
+			if err != nil {
+				t.Fatalf("InitDB() error = %v", err)
+			}
+			defer db.Close()
+
+			if got := schemaVersion(t, db); got != SchemaVersion {
+				t.Errorf("schema version = %d, want %d", got, SchemaVersion)
+			}
+			columns := map[string][]string{
+				"backups": {"object_id", "kind", "codec", "hash", "size", "note", "deleted_at", "game_id"},
+				"objects": {"codec"},
+			}
+			for table, names := range columns {
+				for _, column := range names {
+					if ok, err := hasColumn(db, table, column); err != nil || !ok {
+						t.Errorf("%s.%s missing after migration (err = %v)", table, column, err)
+					}
+				}
+			}
+			for _, table := range []string{"tags", "backup_tags"} {
+				var count int
+				if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&count); err != nil || count != 1 {
+					t.Errorf("table %s missing after migration (err = %v)", table, err)
+				}
+			}
+
+			// The existing backup survives with the new columns' defaults
+			var name, note string
+			if err := db.QueryRow("SELECT name, note FROM backups").Scan(&name, &note); err != nil {
+				t.Fatal(err)
+			}
+			if name != "old" || note != "" {
+				t.Errorf("migrated backup = %q with note %q, want %q without a note", name, note, "old")
+			}
+
+			// The database was copied before the upgrade, in its old state
+			copies, err := filepath.Glob(path + ".v0-*.bak")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(copies) != 1 {
+				t.Fatalf("found backup copies %v, want one", copies)
+			}
+			old, err := sql.Open("sqlite3", copies[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer old.Close()
+			if ok, err := hasColumn(old, "backups", "note"); err != nil || ok {
+				t.Errorf("backup copy has the new note column (err = %v), want the old schema", err)
+			}
+			var versioned int
+			if err := old.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'schema_version'").Scan(&versioned); err != nil || versioned != 0 {
+				t.Errorf("backup copy has a schema_version table (err = %v), want the database as it was", err)
+			}
+		})
+	}
+}
+
+func TestMigrateIsDoneOnce(t *testing.T) {
+	dir := t.TempDir()
+	path := writeDB(t, dir, baselineSchema)
+	for i := 0; i < 2; i++ {
+		db, err := InitDB(dir, "")
+		if err != nil {
+			t.Fatalf("InitDB() #%d error = %v", i+1, err)
+		}
+		db.Close()
+	}
+	if copies, _ := filepath.Glob(path + ".v*.bak"); len(copies) != 1 {
+		t.Errorf("found backup copies %v, want one from the first upgrade", copies)
+	}
+}
+
+func TestMigrateAssignsLegacyBackupsOnce(t *testing.T) {
+	dir := t.TempDir()
+	writeDB(t, dir, baselineSchema)
+
+	db, err := InitDB(dir, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A backup recorded later without a game, as by an import, stays unowned
+	if _, err := db.Exec("INSERT INTO backups (name, path, created_at) VALUES ('later', '', '2026-01-01 00:00:00')"); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	db, err = InitDB(dir, "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	games := make(map[string]string)
+	rows, err := db.Query("SELECT name, game_id FROM backups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, game string
+		if err := rows.Scan(&name, &game); err != nil {
+			t.Fatal(err)
+		}
+		games[name] = game
+	}
+	if want := map[string]string{"old": "default", "later": ""}; !reflect.DeepEqual(games, want) {
+		t.Errorf("backup games = %v, want %v", games, want)
+	}
+}
+
+func TestMigrateCopiesDoNotCollide(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, dbFileName)
+	// Two old databases upgraded in the same second each keep their copy
+	for i := 0; i < 2; i++ {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+		writeDB(t, dir, baselineSchema)
+		db, err := InitDB(dir, "")
+		if err != nil {
+			t.Fatalf("InitDB() #%d error = %v", i+1, err)
+		}
+		db.Close()
+	}
+	if copies, _ := filepath.Glob(path + ".v0-*.bak"); len(copies) != 2 {
+		t.Errorf("found backup copies %v, want one from each upgrade", copies)
+	}
+}
+
+func TestNewDatabaseIsNotCopied(t *testing.T) {
+	dir := t.TempDir()
+	db, err := InitDB(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if got := schemaVersion(t, db); got != SchemaVersion {
+		t.Errorf("schema version = %d, want %d", got, SchemaVersion)
+	}
+	if copies, _ := filepath.Glob(filepath.Join(dir, dbFileName+".v*.bak")); len(copies) != 0 {
+		t.Errorf("found backup copies %v of a new database", copies)
+	}
+}
+
+func TestNewerSchemaIsRefused(t *testing.T) {
+	dir := t.TempDir()
+	path := writeDB(t, dir, baselineSchema+`
+		CREATE TABLE schema_version (version INTEGER NOT NULL);
+		INSERT INTO schema_version (version) VALUES (999);
+	`)
+
+	db, err := InitDB(dir, "")
+	if err == nil {
+		db.Close()
+		t.Fatal("InitDB() succeeded on a database from a newer version")
+	}
+	if !strings.Contains(err.Error(), "schema version 999") {
+		t.Errorf("InitDB() error = %v, want one naming schema version 999", err)
+	}
+
+	// Nothing was changed or copied
+	raw, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	if got := schemaVersion(t, raw); got != 999 {
+		t.Errorf("schema version = %d after refusing, want 999", got)
+	}
+	if ok, err := hasColumn(raw, "backups", "note"); err != nil || ok {
+		t.Errorf("refused database was migrated (note column present, err = %v)", err)
+	}
+	if copies, _ := filepath.Glob(path + ".v*.bak"); len(copies) != 0 {
+		t.Errorf("found backup copies %v of a refused database", copies)
+	}
+}
@@ -0,0 +1,187 @@
+package backup
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// OrphanFile is a backup file in the backup directory with no database row.
+type OrphanFile struct {
+	Path    string
+	ModTime time.Time
+	Size    int64
+}
+
+// ReconcileReport lists the differences between the database and the disk.
+type ReconcileReport struct {
+	Missing       []Backup     // rows whose backup data is gone or incomplete
+	Orphans       []OrphanFile // .sav files nobody has recorded
+	UnusedObjects []string     // objects in the store that no row references
+}
+
+// Clean returns true if the database and the disk agree.
+func (r *ReconcileReport) Clean() bool {
+	return len(r.Missing) == 0 && len(r.Orphans) == 0 && len(r.UnusedObjects) == 0
+}
+
+// Reconcile compares a game's backups with the disk and reports rows whose
+// data is gone or incomplete, stray .sav files in dir and unreferenced
+// objects. Backups in the trash are checked too. An empty dir skips looking
+// for stray files.
+func (db *DB) Reconcile(gameID, dir string) (*ReconcileReport, error) {
+	report := &ReconcileReport{}
+
+	backups, err := db.allBackups()
+	if err != nil {
+		return nil, err
+	}
+
+	// Files recorded by any game are not strays
+	known := make(map[string]struct{})
+	for _, b := range backups {
+		known[filepath.Clean(b.Path)] = struct{}{}
+		if b.GameID != gameID {
+			continue
+		}
+
+		if !db.hasData(b) {
+			report.Missing = append(report.Missing, b)
+		}
+	}
+
+	var entries []os.DirEntry
+	if dir != "" {
+		entries, err = os.ReadDir(dir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.EqualFold(filepath.Ext(e.Name()), ".sav") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if _, ok := known[filepath.Clean(path)]; ok {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		report.Orphans = append(report.Orphans, OrphanFile{Path: path, ModTime: info.ModTime(), Size: info.Size()})
+	}
+
+	report.UnusedObjects, err = db.unusedObjects()
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// hasData reports whether everything a backup needs to be restored is on
+// disk: its file, or its object and, for a tree, every object it lists.
+func (db *DB) hasData(b Backup) bool {
+	if b.ObjectID == "" {
+		_, err := os.Lstat(b.Path)
+		return err == nil
+	}
+	if !db.store.Has(b.ObjectID) {
+		return false
+	}
+	if b.Kind != KindTree {
+		return true
+	}
+
+	// A manifest that can't be read is as good as missing
+	tree, err := db.store.ReadTree(b.ObjectID)
+	if err != nil {
+		return false
+	}
+	for _, id := range tree.Objects() {
+		if !db.store.Has(id) {
+			return false
+		}
+	}
+	return true
+}
+
+// unusedObjects returns IDs of objects on disk that the database does not track.
+func (db *DB) unusedObjects() ([]string, error) {
+	rows, err := db.Query("SELECT id FROM objects")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tracked := make(map[string]struct{})
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		tracked[id] = struct{}{}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var unused []string
+	err = filepath.WalkDir(db.store.root, func(path string, d fs.DirEntry, err error) error {
+		if os.IsNotExist(err) && path == db.store.root {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+
+		// Object files are named <first two hex digits>/<rest>[.ext]
+		name := strings.TrimSuffix(d.Name(), filepath.Ext(d.Name()))
+		id := filepath.Base(filepath.Dir(path)) + name
+		if _, ok := tracked[id]; !ok {
+			unused = append(unused, id)
+		}
+		return nil
+	})
+	return unused, err
+}
+
+// AdoptOrphans records stray backup files in the database as backups of the
+// given game, using each file's modification time as its creation time. The
+// files are left where they are.
+func (db *DB) AdoptOrphans(gameID string, orphans []OrphanFile) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, o := range orphans {
+		sum, size, err := HashFile(o.Path)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(filepath.Base(o.Path), filepath.Ext(o.Path))
+		_, err = tx.Exec("INSERT INTO backups (name, path, created_at, codec, hash, size, game_id) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			name, o.Path, o.ModTime, CodecNone, sum, size, gameID)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RemoveObjects deletes objects from the store that the database does not track.
+func (db *DB) RemoveObjects(ids []string) error {
+	for _, id := range ids {
+		if err := db.store.Remove(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
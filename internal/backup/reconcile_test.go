@@ -0,0 +1,64 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReconcileChecksTreeObjects(t *testing.T) {
+	tests := []struct {
+		name    string
+		damage  func(t *testing.T, db *DB, b Backup, tree *Tree)
+		missing bool
+	}{
+		{"intact", func(*testing.T, *DB, Backup, *Tree) {}, false},
+		{"manifest removed", func(t *testing.T, db *DB, b Backup, _ *Tree) {
+			if err := db.store.Remove(b.ObjectID); err != nil {
+				t.Fatal(err)
+			}
+		}, true},
+		{"manifest unreadable", func(t *testing.T, db *DB, b Backup, _ *Tree) {
+			if err := os.WriteFile(db.store.ObjectPath(b.ObjectID), []byte("not a tree"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}, true},
+		{"file object removed", func(t *testing.T, db *DB, _ Backup, tree *Tree) {
+			if err := db.store.Remove(tree.Objects()[0]); err != nil {
+				t.Fatal(err)
+			}
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDB(t)
+			save := t.TempDir()
+			for _, name := range []string{"a.sav", "b.sav"} {
+				if err := os.WriteFile(filepath.Join(save, name), []byte(name), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			created, err := db.CreateBackup(save, "tree", CreateOptions{GameID: "game"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			tree, err := db.store.ReadTree(created.ObjectID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.damage(t, db, created, tree)
+
+			report, err := db.Reconcile("game", t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var missing []int
+			for _, b := range report.Missing {
+				missing = append(missing, b.ID)
+			}
+			if reported := len(missing) == 1 && missing[0] == created.ID; reported != tt.missing || len(missing) > 1 {
+				t.Errorf("Missing = %v, want backup %d reported: %v", missing, created.ID, tt.missing)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package backup
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// syncDir flushes a directory entry to disk so renames within it are durable.
+// Not every platform supports syncing directories, so failures are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
+
+// syncTreeDirs fsyncs every directory below root, so the entries of the
+// files written into them are durable. The files themselves are flushed by
+// whoever writes them, before they are closed.
+func syncTreeDirs(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			syncDir(path)
+		}
+		return nil
+	})
+}
+
+// atomicWriteFile replaces the file at dst with the contents of r. The data is
+// written to a temporary file next to dst, flushed and renamed into place, so
+// dst is either left untouched or fully replaced.
+func atomicWriteFile(dst string, r io.Reader) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(dst); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	dir := filepath.Dir(dst)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(dst)+".restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// atomicReplaceDir replaces the directory at dst with a new tree. fill is
+// called to populate a temporary directory next to dst; once it succeeds and
+// the tree has been flushed, the old directory is swapped out for the new one.
+// If anything fails before the swap, dst is left untouched.
+func atomicReplaceDir(dst string, fill func(tmp string) error) error {
+	dir := filepath.Dir(dst)
+	base := filepath.Base(dst)
+
+	tmp, err := os.MkdirTemp(dir, "."+base+".restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := fill(tmp); err != nil {
+		return err
+	}
+	if err := syncTreeDirs(tmp); err != nil {
+		return err
+	}
+
+	// Keep the directory permissions of the save the game created
+	if info, err := os.Stat(dst); err == nil {
+		os.Chmod(tmp, info.Mode().Perm())
+	}
+
+	if _, err := os.Lstat(dst); os.IsNotExist(err) {
+		if err := os.Rename(tmp, dst); err != nil {
+			return err
+		}
+		syncDir(dir)
+		return nil
+	}
+
+	// Move the current save aside, then move the new tree into place. If the
+	// second rename fails, put the original back.
+	old, err := os.MkdirTemp(dir, "."+base+".old-*")
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(old); err != nil {
+		return err
+	}
+	if err := os.Rename(dst, old); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		if rerr := os.Rename(old, dst); rerr != nil {
+			return &RollbackError{Err: err, RollbackErr: rerr, SavedAt: old}
+		}
+		return err
+	}
+	syncDir(dir)
+
+	// The restore has succeeded at this point; a leftover copy of the old
+	// save is not worth failing over
+	os.RemoveAll(old)
+	return nil
+}
+
+// RollbackError reports a restore that failed and whose rollback also failed.
+// The original save is still intact at SavedAt.
+type RollbackError struct {
+	Err         error
+	RollbackErr error
+	SavedAt     string
+}
+
+func (e *RollbackError) Error() string {
+	return "restore failed (" + e.Err.Error() + ") and the original save could not be put back (" +
+		e.RollbackErr.Error() + "); it is preserved at " + e.SavedAt
+}
+
+func (e *RollbackError) Unwrap() error {
+	return e.Err
+}
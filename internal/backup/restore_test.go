@@ -0,0 +1,46 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRestoreReadOnlyFile(t *testing.T) {
+	db := testDB(t)
+	save := filepath.Join(t.TempDir(), "save")
+	if err := os.MkdirAll(save, 0755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(save, "profile.sav")
+	if err := os.WriteFile(file, []byte("original"), 0444); err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := db.CreateBackup(save, "first", CreateOptions{GameID: "game"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RestoreBackup(created, save); err != nil {
+		t.Fatalf("RestoreBackup() error = %v", err)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "original" {
+		t.Errorf("restored contents = %q, want %q", data, "original")
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0444 {
+		t.Errorf("restored mode = %v, want %v", perm, os.FileMode(0444))
+	}
+}
@@ -0,0 +1,410 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// Object kinds stored in the object store.
+const (
+	KindBlob = "blob" // raw file contents
+	KindTree = "tree" // JSON manifest describing a directory save
+)
+
+// Store is a content-addressed object store. Objects are keyed by the
+// SHA-256 of their contents, so identical content is only stored once.
+type Store struct {
+	root string
+}
+
+// NewStore returns the object store kept under backupDir.
+func NewStore(backupDir string) *Store {
+	return &Store{root: filepath.Join(backupDir, "objects")}
+}
+
+// objectFile returns where an object stored with the given codec lives on disk.
+func (s *Store) objectFile(id, ext string) string {
+	if len(id) < 2 {
+		return filepath.Join(s.root, id+ext)
+	}
+	return filepath.Join(s.root, id[:2], id[2:]+ext)
+}
+
+// locate finds the file holding an object and the codec it was written with.
+func (s *Store) locate(id string) (path, codec string, ok bool) {
+	for _, c := range codecs {
+		path := s.objectFile(id, c.ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, c.name, true
+		}
+	}
+	return "", "", false
+}
+
+// ObjectPath returns the on-disk location of an object.
+func (s *Store) ObjectPath(id string) string {
+	if path, _, ok := s.locate(id); ok {
+		return path
+	}
+	return s.objectFile(id, "")
+}
+
+// Has reports whether the object exists on disk.
+func (s *Store) Has(id string) bool {
+	_, _, ok := s.locate(id)
+	return ok
+}
+
+// ObjectInfo describes an object written to the store.
+type ObjectInfo struct {
+	ID    string // SHA-256 of the uncompressed contents
+	Size  int64  // uncompressed size
+	Codec string // codec the object is stored with
+}
+
+// Put stores the contents of r compressed with c. Objects are keyed by their
+// uncompressed contents; if the object already exists it is left as is, and
+// the returned info reports the codec it was originally stored with.
+func (s *Store) Put(r io.Reader, c Compression) (info ObjectInfo, created bool, err error) {
+	ext, err := codecExt(c.Codec)
+	if err != nil {
+		return info, false, err
+	}
+	if err := os.MkdirAll(s.root, 0755); err != nil {
+		return info, false, err
+	}
+
+	tmp, err := os.CreateTemp(s.root, ".incoming-*")
+	if err != nil {
+		return info, false, err
+	}
+	defer os.Remove(tmp.Name())
+
+	cw, err := newCompressor(tmp, c)
+	if err != nil {
+		tmp.Close()
+		return info, false, err
+	}
+	h := sha256.New()
+	info.Size, err = io.Copy(io.MultiWriter(cw, h), r)
+	if err == nil {
+		err = cw.Close()
+	}
+	if err != nil {
+		tmp.Close()
+		return info, false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return info, false, err
+	}
+
+	info.ID = hex.EncodeToString(h.Sum(nil))
+	if _, codec, ok := s.locate(info.ID); ok {
+		info.Codec = codec
+		return info, false, nil
+	}
+
+	dst := s.objectFile(info.ID, ext)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return info, false, err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return info, false, err
+	}
+	info.Codec = c.Codec
+	if info.Codec == "" {
+		info.Codec = CodecNone
+	}
+	return info, true, nil
+}
+
+// PutFile stores the contents of the file at path.
+func (s *Store) PutFile(path string, c Compression) (ObjectInfo, bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ObjectInfo{}, false, err
+	}
+	defer f.Close()
+	return s.Put(f, c)
+}
+
+// objectReader closes both the decompressor and the underlying file.
+type objectReader struct {
+	io.ReadCloser
+	file *os.File
+}
+
+func (r objectReader) Close() error {
+	r.ReadCloser.Close()
+	return r.file.Close()
+}
+
+// Open opens an object for reading, decompressing it on the fly.
+func (s *Store) Open(id string) (io.ReadCloser, error) {
+	path, codec, ok := s.locate(id)
+	if !ok {
+		return nil, fmt.Errorf("object %s is missing from the store", short(id))
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newDecompressor(f, codec)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("object %s: %v", id, err)
+	}
+	return objectReader{ReadCloser: r, file: f}, nil
+}
+
+// Remove deletes an object from disk.
+func (s *Store) Remove(id string) error {
+	for _, c := range codecs {
+		err := os.Remove(s.objectFile(id, c.ext))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// TreeEntry describes one entry of a directory save.
+type TreeEntry struct {
+	Path    string      `json:"path"`
+	Mode    fs.FileMode `json:"mode"`
+	ModTime time.Time   `json:"mod_time"`
+	Size    int64       `json:"size,omitempty"`
+	Object  string      `json:"object,omitempty"`
+	Link    string      `json:"link,omitempty"`
+}
+
+// Tree is the manifest stored for a directory save.
+type Tree struct {
+	Entries []TreeEntry `json:"entries"`
+
+	// Filter records the include and exclude patterns the save was taken
+	// with. Restores leave files outside the filter alone.
+	Filter *Filter `json:"filter,omitempty"`
+}
+
+// Objects returns the distinct blob IDs referenced by the tree.
+func (t *Tree) Objects() []string {
+	seen := make(map[string]struct{})
+	var ids []string
+	for _, e := range t.Entries {
+		if e.Object == "" {
+			continue
+		}
+		if _, ok := seen[e.Object]; ok {
+			continue
+		}
+		seen[e.Object] = struct{}{}
+		ids = append(ids, e.Object)
+	}
+	return ids
+}
+
+// Validate checks that every entry of the tree stays inside the directory it
+// is restored to: paths must be relative and clean, without ".." elements,
+// appear only once, and not lead through a symbolic link of the tree.
+func (t *Tree) Validate() error {
+	seen := make(map[string]struct{}, len(t.Entries))
+	links := make(map[string]struct{})
+	for _, e := range t.Entries {
+		if e.Path != path.Clean(e.Path) || !filepath.IsLocal(filepath.FromSlash(e.Path)) {
+			return fmt.Errorf("tree entry %q is outside the save directory", e.Path)
+		}
+		if _, ok := seen[e.Path]; ok {
+			return fmt.Errorf("tree entry %q appears more than once", e.Path)
+		}
+		seen[e.Path] = struct{}{}
+		if e.Mode&fs.ModeSymlink != 0 {
+			links[e.Path] = struct{}{}
+		}
+	}
+	for _, e := range t.Entries {
+		for dir := path.Dir(e.Path); dir != "."; dir = path.Dir(dir) {
+			if _, ok := links[dir]; ok {
+				return fmt.Errorf("tree entry %q is inside the symbolic link %q", e.Path, dir)
+			}
+		}
+	}
+	return nil
+}
+
+// snapshot is the result of storing a save in the object store.
+type snapshot struct {
+	root    ObjectInfo            // root object
+	kind    string                // KindBlob or KindTree
+	size    int64                 // total size of the save contents
+	objects map[string]ObjectInfo // every object referenced
+	created []string              // objects newly written by this snapshot
+}
+
+// snapshot stores the save at savePath, which may be a file or a directory.
+// Only the files of a directory save that f selects are stored.
+func (s *Store) snapshot(savePath string, c Compression, f Filter) (*snapshot, error) {
+	info, err := os.Stat(savePath)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &snapshot{objects: make(map[string]ObjectInfo)}
+	add := func(obj ObjectInfo, created bool) {
+		snap.objects[obj.ID] = obj
+		if created {
+			snap.created = append(snap.created, obj.ID)
+		}
+	}
+
+	if !info.IsDir() {
+		obj, created, err := s.PutFile(savePath, c)
+		if err != nil {
+			return nil, err
+		}
+		add(obj, created)
+		snap.root, snap.kind, snap.size = obj, KindBlob, obj.Size
+		return snap, nil
+	}
+
+	var tree Tree
+	err = walkSave(savePath, f, func(path, rel string, d fs.DirEntry) error {
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		entry := TreeEntry{Path: rel, Mode: info.Mode(), ModTime: info.ModTime()}
+		switch {
+		case d.IsDir():
+		case d.Type()&fs.ModeSymlink != 0:
+			if entry.Link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		case d.Type().IsRegular():
+			obj, created, err := s.PutFile(path, c)
+			if err != nil {
+				return err
+			}
+			add(obj, created)
+			entry.Object, entry.Size = obj.ID, obj.Size
+			snap.size += obj.Size
+		default:
+			// Sockets, devices and the like are not part of a save
+			return nil
+		}
+		tree.Entries = append(tree.Entries, entry)
+		return nil
+	})
+	if err != nil {
+		s.discard(snap.created)
+		return nil, err
+	}
+
+	tree.Entries = pruneDirs(tree.Entries, f)
+	if !f.IsEmpty() {
+		tree.Filter = &f
+	}
+
+	data, err := json.Marshal(&tree)
+	if err != nil {
+		s.discard(snap.created)
+		return nil, err
+	}
+	obj, created, err := s.Put(bytes.NewReader(data), c)
+	if err != nil {
+		s.discard(snap.created)
+		return nil, err
+	}
+	add(obj, created)
+	snap.root, snap.kind = obj, KindTree
+	return snap, nil
+}
+
+// discard removes objects that were written by a snapshot that was not recorded.
+func (s *Store) discard(ids []string) {
+	for _, id := range ids {
+		s.Remove(id)
+	}
+}
+
+// ReadTree loads a tree manifest from the store.
+func (s *Store) ReadTree(id string) (*Tree, error) {
+	r, err := s.Open(id)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var tree Tree
+	if err := json.NewDecoder(r).Decode(&tree); err != nil {
+		return nil, fmt.Errorf("invalid tree object %s: %v", id, err)
+	}
+	return &tree, nil
+}
+
+// extractBlob writes a blob object to dst and flushes it to disk.
+func (s *Store) extractBlob(id, dst string, mode fs.FileMode) error {
+	r, err := s.Open(id)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// extractTree materializes a tree manifest into the directory dst.
+func (s *Store) extractTree(tree *Tree, dst string) error {
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+
+	// Directories are recorded before their contents, so a single pass works
+	for _, e := range tree.Entries {
+		target := filepath.Join(dst, filepath.FromSlash(e.Path))
+		if rel, err := filepath.Rel(dst, target); err != nil || !filepath.IsLocal(rel) {
+			return fmt.Errorf("tree entry %q is outside the save directory", e.Path)
+		}
+		switch {
+		case e.Mode.IsDir():
+			if err := os.MkdirAll(target, e.Mode.Perm()|0700); err != nil {
+				return err
+			}
+		case e.Mode&fs.ModeSymlink != 0:
+			if err := os.Symlink(e.Link, target); err != nil {
+				return err
+			}
+		default:
+			if err := s.extractBlob(e.Object, target, e.Mode); err != nil {
+				return err
+			}
+			if err := os.Chtimes(target, e.ModTime, e.ModTime); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// trashDirName is the folder inside the backup directory that holds the
+// files of legacy backups while they are in the trash.
+const trashDirName = ".trash"
+
+// DeleteBackups moves backups to the trash. Their data is kept until they
+// are purged, so they can still be restored from the trash.
+func (db *DB) DeleteBackups(backups []Backup) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Files moved so far, so they can be put back if the transaction fails
+	var moved []fileMove
+
+	now := time.Now()
+	for _, b := range backups {
+		path := b.Path
+		// Legacy backups are plain files in the backup directory; move them
+		// out of the way so they do not show up as strays
+		if b.ObjectID == "" {
+			if _, err := os.Lstat(b.Path); err == nil {
+				dst, err := db.trashPath(b.Path)
+				if err != nil {
+					undoMoves(moved)
+					return err
+				}
+				if err := os.Rename(b.Path, dst); err != nil {
+					undoMoves(moved)
+					return err
+				}
+				moved = append(moved, fileMove{b.Path, dst})
+				path = dst
+			}
+		}
+
+		if _, err := tx.Exec("UPDATE backups SET deleted_at = ?, path = ? WHERE id = ?", now, path, b.ID); err != nil {
+			undoMoves(moved)
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		undoMoves(moved)
+		return err
+	}
+	return nil
+}
+
+// fileMove records a rename so it can be undone.
+type fileMove struct {
+	from, to string
+}
+
+// undoMoves puts moved files back where they came from.
+func undoMoves(moves []fileMove) {
+	for i := len(moves) - 1; i >= 0; i-- {
+		os.Rename(moves[i].to, moves[i].from)
+	}
+}
+
+// trashPath returns a free location in the trash for the file at path.
+func (db *DB) trashPath(path string) (string, error) {
+	dir := filepath.Join(db.dir, trashDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return freePath(dir, filepath.Base(path))
+}
+
+// freePath returns dir/name, adding a numeric suffix if that is taken.
+func freePath(dir, name string) (string, error) {
+	ext := filepath.Ext(name)
+	base := name[:len(name)-len(ext)]
+	candidate := filepath.Join(dir, name)
+	for counter := 1; ; counter++ {
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate, nil
+		} else if err != nil {
+			return "", err
+		}
+		candidate = filepath.Join(dir, fmt.Sprintf("%s_%d%s", base, counter, ext))
+	}
+}
+
+// GetTrashedBackups retrieves a game's backups in the trash, most recently deleted first.
+func (db *DB) GetTrashedBackups(gameID string) ([]Backup, error) {
+	return db.queryBackups("WHERE game_id = ? AND deleted_at IS NOT NULL ORDER BY deleted_at DESC", gameID)
+}
+
+// RestoreFromTrash takes backups out of the trash.
+func (db *DB) RestoreFromTrash(backups []Backup) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var moved []fileMove
+	for _, b := range backups {
+		path := b.Path
+		if b.ObjectID == "" {
+			if _, err := os.Lstat(b.Path); err == nil {
+				dst, err := freePath(db.dir, filepath.Base(b.Path))
+				if err != nil {
+					undoMoves(moved)
+					return err
+				}
+				if err := os.Rename(b.Path, dst); err != nil {
+					undoMoves(moved)
+					return err
+				}
+				moved = append(moved, fileMove{b.Path, dst})
+				path = dst
+			}
+		}
+
+		if _, err := tx.Exec("UPDATE backups SET deleted_at = NULL, path = ? WHERE id = ?", path, b.ID); err != nil {
+			undoMoves(moved)
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		undoMoves(moved)
+		return err
+	}
+	return nil
+}
+
+// PurgeExpired permanently deletes backups that have been in the trash for
+// longer than maxAge and returns how many were removed.
+func (db *DB) PurgeExpired(maxAge time.Duration) (int, error) {
+	expired, err := db.queryBackups("WHERE deleted_at IS NOT NULL AND deleted_at < ?", time.Now().Add(-maxAge))
+	if err != nil {
+		return 0, err
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+	return len(expired), db.PurgeBackups(expired)
+}
@@ -0,0 +1,182 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// VerifyStatus is the outcome of checking a single backup.
+type VerifyStatus int
+
+const (
+	VerifyOK        VerifyStatus = iota // contents match the recorded checksum
+	VerifyMissing                       // backup data is gone from disk
+	VerifyCorrupted                     // backup data exists but cannot be read
+	VerifyMismatch                      // backup data reads fine but does not match its checksum
+	VerifyUnchecked                     // no checksum was recorded for this backup
+)
+
+// String returns a human-readable name for the status.
+func (s VerifyStatus) String() string {
+	switch s {
+	case VerifyOK:
+		return "OK"
+	case VerifyMissing:
+		return "Missing"
+	case VerifyCorrupted:
+		return "Corrupted"
+	case VerifyMismatch:
+		return "Mismatched"
+	case VerifyUnchecked:
+		return "Unchecked"
+	}
+	return "Unknown"
+}
+
+// VerifyResult reports the integrity of one backup.
+type VerifyResult struct {
+	Backup Backup
+	Status VerifyStatus
+	Detail string
+}
+
+// hashReader returns the SHA-256 and length of everything read from r.
+func hashReader(r io.Reader) (string, int64, error) {
+	h := sha256.New()
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return "", n, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// HashFile returns the SHA-256 and size of the file at path.
+func HashFile(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	return hashReader(f)
+}
+
+// verifyObject rehashes an object and checks it against its ID.
+func (s *Store) verifyObject(id string) (int64, VerifyStatus, error) {
+	if !s.Has(id) {
+		return 0, VerifyMissing, fmt.Errorf("object %s is missing", short(id))
+	}
+
+	r, err := s.Open(id)
+	if err != nil {
+		return 0, VerifyCorrupted, err
+	}
+	defer r.Close()
+
+	sum, size, err := hashReader(r)
+	if err != nil {
+		return 0, VerifyCorrupted, fmt.Errorf("object %s is unreadable: %v", short(id), err)
+	}
+	if sum != id {
+		return size, VerifyMismatch, fmt.Errorf("object %s does not match its checksum", short(id))
+	}
+	return size, VerifyOK, nil
+}
+
+// VerifyBackup rehashes the stored contents of a backup and compares them
+// with the checksum and size recorded when it was created.
+func (db *DB) VerifyBackup(b Backup) VerifyResult {
+	result := VerifyResult{Backup: b, Status: VerifyOK}
+	fail := func(status VerifyStatus, format string, args ...interface{}) VerifyResult {
+		result.Status = status
+		result.Detail = fmt.Sprintf(format, args...)
+		return result
+	}
+
+	if b.ObjectID == "" {
+		return db.verifyLegacy(b)
+	}
+
+	// Backups stored before checksums were recorded are still keyed by content
+	expected := b.Hash
+	if expected == "" {
+		expected = b.ObjectID
+	}
+	if expected != b.ObjectID {
+		return fail(VerifyMismatch, "recorded checksum %s does not match stored object %s", short(expected), short(b.ObjectID))
+	}
+
+	size, status, err := db.store.verifyObject(b.ObjectID)
+	if err != nil {
+		return fail(status, "%v", err)
+	}
+
+	if b.Kind == KindTree {
+		tree, err := db.store.ReadTree(b.ObjectID)
+		if err != nil {
+			return fail(VerifyCorrupted, "%v", err)
+		}
+
+		size = 0
+		for _, e := range tree.Entries {
+			if e.Object == "" {
+				continue
+			}
+			n, status, err := db.store.verifyObject(e.Object)
+			if err != nil {
+				return fail(status, "%s: %v", e.Path, err)
+			}
+			if n != e.Size {
+				return fail(VerifyMismatch, "%s: size is %d bytes, expected %d", e.Path, n, e.Size)
+			}
+			size += n
+		}
+	}
+
+	if b.Hash != "" && size != b.Size {
+		return fail(VerifyMismatch, "size is %d bytes, expected %d", size, b.Size)
+	}
+	result.Detail = fmt.Sprintf("%d bytes, sha256 %s", size, short(b.ObjectID))
+	return result
+}
+
+// verifyLegacy checks a backup made before the object store existed.
+func (db *DB) verifyLegacy(b Backup) VerifyResult {
+	result := VerifyResult{Backup: b}
+
+	info, err := os.Stat(b.Path)
+	if os.IsNotExist(err) {
+		result.Status, result.Detail = VerifyMissing, "backup file not found: "+b.Path
+		return result
+	}
+	if err != nil {
+		result.Status, result.Detail = VerifyCorrupted, err.Error()
+		return result
+	}
+
+	if !info.IsDir() && b.Hash != "" {
+		sum, size, err := HashFile(b.Path)
+		switch {
+		case err != nil:
+			result.Status, result.Detail = VerifyCorrupted, err.Error()
+		case sum != b.Hash || size != b.Size:
+			result.Status, result.Detail = VerifyMismatch, "contents do not match the recorded checksum"
+		default:
+			result.Status, result.Detail = VerifyOK, fmt.Sprintf("%d bytes, sha256 %s", size, short(sum))
+		}
+		return result
+	}
+
+	result.Status, result.Detail = VerifyUnchecked, "no checksum recorded for this backup"
+	return result
+}
+
+// short abbreviates a hash for display.
+func short(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
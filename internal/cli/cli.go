@@ -0,0 +1,522 @@
+// Package cli runs backup operations from the command line without the TUI,
+// so they can be scripted from cron jobs, launch wrappers or over SSH.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/backup"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/components"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/config"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/doctor"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/selector"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/services"
+)
+
+// Exit codes returned by Run.
+const (
+	ExitOK       = 0
+	ExitError    = 1 // the operation failed
+	ExitUsage    = 2 // the command line was wrong
+	ExitNotFound = 3 // no backup matches the selector
+)
+
+// command is one subcommand.
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(e *env, fs *flag.FlagSet, args []string) error
+}
+
+var commands = []command{
+	{"create", "[name]", "back up the active game's save", runCreate},
+	{"list", "[selector]", "list the active game's backups, newest first", runList},
+	{"restore", "<selector>", "restore a backup over the active game's save", runRestore},
+	{"delete", "<selector>", "move the selected backups to the trash", runDelete},
+	{"info", "<selector>", "show the details of a backup", runInfo},
+	{"export", "<selector>", "write the selected backups to a bundle file", runExport},
+	{"import", "<file>", "add the backups in a bundle file", runImport},
+	{"doctor", "", "check the configuration, directories and database, and explain how to fix problems", runDoctor},
+}
+
+// IsCommand reports whether name is a subcommand.
+func IsCommand(name string) bool {
+	_, ok := findCommand(name)
+	return ok
+}
+
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// PrintCommands writes the list of subcommands for a usage message.
+func PrintCommands(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(tw, "  %s %s\t%s\n", c.name, c.args, c.summary)
+	}
+	tw.Flush()
+}
+
+// Setup is what was found out about the configuration before a command runs.
+type Setup struct {
+	Config   *config.Config  // the configuration with overrides applied
+	Location config.Location // where the config file is
+	LoadErr  error           // why the configuration could not be loaded
+	FirstRun bool            // the config file does not exist yet
+}
+
+// env is what a subcommand works with.
+type env struct {
+	cfg     *config.Config
+	setup   Setup
+	service *services.BackupService
+	stdout  io.Writer
+}
+
+// usageError is a mistake on the command line. Mistakes in flags have
+// already been reported by the flag package.
+type usageError struct {
+	msg      string
+	reported bool
+}
+
+func (e usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...interface{}) error {
+	return usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// notFoundError is a selector that matches no backup.
+type notFoundError struct{ query string }
+
+func (e notFoundError) Error() string {
+	return fmt.Sprintf("no backup matches %q", e.query)
+}
+
+// Run runs the subcommand in args[0] with the rest of args, and returns the
+// exit code. Results are written to stdout and errors to stderr.
+func Run(setup Setup, args []string, stdout, stderr io.Writer) int {
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\nCommands:\n", args[0])
+		PrintCommands(stderr)
+		return ExitUsage
+	}
+
+	// Only doctor runs without a usable configuration, to explain what is wrong
+	if cmd.name != "doctor" {
+		if setup.LoadErr != nil {
+			fmt.Fprintf(stderr, "%s: %v\nRun the doctor command for help fixing this.\n", cmd.name, setup.LoadErr)
+			return ExitError
+		}
+		if setup.FirstRun && !setup.Config.Complete() {
+			fmt.Fprintf(stderr, "%s: no configuration found at %s; run without a command to set one up, "+
+				"or give the backup directory and save path with $%sBACKUP_DIR and $%sSAVE_PATH\n",
+				cmd.name, setup.Location.Path, config.EnvPrefix, config.EnvPrefix)
+			return ExitError
+		}
+	}
+
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: %s\n\n%s.\n", strings.TrimSpace(cmd.name+" "+cmd.args), strings.ToUpper(cmd.summary[:1])+cmd.summary[1:])
+		fs.PrintDefaults()
+	}
+	e := &env{cfg: setup.Config, setup: setup, stdout: stdout}
+
+	err := cmd.run(e, fs, args[1:])
+	if e.service != nil {
+		e.service.Close()
+	}
+	if err == nil {
+		return ExitOK
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+
+	var usage usageError
+	if errors.As(err, &usage) {
+		if !usage.reported {
+			fmt.Fprintf(stderr, "%s: %v\n", cmd.name, err)
+			fs.Usage()
+		}
+		return ExitUsage
+	}
+
+	fmt.Fprintf(stderr, "%s: %v\n", cmd.name, err)
+	var notFound notFoundError
+	if errors.As(err, &notFound) {
+		return ExitNotFound
+	}
+	return ExitError
+}
+
+// parse parses a subcommand's flags and checks how many arguments are left.
+// Flags may come before or after the arguments, up to a "--".
+func parse(fs *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	var rest []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, usageError{msg: err.Error(), reported: true}
+		}
+		left := fs.Args()
+		if len(left) == 0 {
+			break
+		}
+		if used := len(args) - len(left); used > 0 && args[used-1] == "--" {
+			rest = append(rest, left...)
+			break
+		}
+		rest = append(rest, left[0])
+		args = left[1:]
+	}
+	if len(rest) < min {
+		return nil, usagef("missing argument")
+	}
+	if max >= 0 && len(rest) > max {
+		return nil, usagef("too many arguments")
+	}
+	return rest, nil
+}
+
+// parseSelector parses the flags of a command whose arguments form a
+// selector. Negated terms such as -tag:auto look like flags, so they are set
+// aside first.
+func parseSelector(fs *flag.FlagSet, args []string, min int) ([]string, error) {
+	var negated, rest []string
+	for i, arg := range args {
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		if isNegatedTerm(arg) {
+			negated = append(negated, arg)
+		} else {
+			rest = append(rest, arg)
+		}
+	}
+	terms, err := parse(fs, rest, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	terms = append(terms, negated...)
+	if len(terms) < min {
+		return nil, usagef("missing selector")
+	}
+	return terms, nil
+}
+
+// isNegatedTerm reports whether arg is a selector term like -tag:auto
+// rather than a flag.
+func isNegatedTerm(arg string) bool {
+	if !strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "--") {
+		return false
+	}
+	name, _, _ := strings.Cut(arg[1:], "=")
+	return strings.Contains(name, ":")
+}
+
+// open opens the backup database of the configured backup directory.
+func (e *env) open() error {
+	if e.cfg.ActiveGameProfile() == nil {
+		return fmt.Errorf("no game is configured; run without a command to set one up")
+	}
+	e.service = services.NewBackupService(nil, e.cfg)
+	if err := e.service.InitializeDatabase(); err != nil {
+		e.service = nil
+		return err
+	}
+	return nil
+}
+
+// find returns the backups a selector given as arguments picks, newest
+// first. With one set, the selector must pick exactly one backup.
+func (e *env) find(args []string, one bool) ([]backup.Backup, error) {
+	sel, err := selector.ParseTerms(args)
+	if err != nil {
+		return nil, usagef("%v", err)
+	}
+	found, err := e.service.SelectBackups(sel)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, notFoundError{query: sel.String()}
+	}
+	if one && len(found) > 1 {
+		names := make([]string, 0, 3)
+		for _, b := range found[:min(len(found), 3)] {
+			names = append(names, fmt.Sprintf("%d %s", b.ID, b.Name))
+		}
+		if len(found) > 3 {
+			names = append(names, "...")
+		}
+		return nil, fmt.Errorf("%q matches %d backups (%s); add latest to pick the newest",
+			sel.String(), len(found), strings.Join(names, ", "))
+	}
+
+	// Work on the game the backups belong to, which game: may have changed
+	e.cfg.SetActiveGame(found[0].GameID)
+	return found, nil
+}
+
+func runCreate(e *env, fs *flag.FlagSet, args []string) error {
+	note := fs.String("note", "", "attach a note to the backup")
+	tags := fs.String("tags", "", "comma-separated tags to attach to the backup")
+	rest, err := parse(fs, args, 0, 1)
+	if err != nil {
+		return err
+	}
+	name := ""
+	if len(rest) == 1 {
+		name = strings.TrimSpace(rest[0])
+	}
+
+	if err := e.open(); err != nil {
+		return err
+	}
+	created, err := e.service.CreateBackup(name)
+	if err != nil {
+		return err
+	}
+	if *note != "" {
+		if err := e.service.SetNote(created, *note); err != nil {
+			return err
+		}
+	}
+	if *tags != "" {
+		if err := e.service.SetTags(created, backup.ParseTags(*tags)); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(e.stdout, "Created backup %d: %s\n", created.ID, created.Name)
+	return nil
+}
+
+func runList(e *env, fs *flag.FlagSet, args []string) error {
+	out := addOutputFlags(fs)
+	terms, err := parseSelector(fs, args, 0)
+	if err != nil {
+		return err
+	}
+	if err := out.check(); err != nil {
+		return err
+	}
+	sel, err := selector.ParseTerms(terms)
+	if err != nil {
+		return usagef("%v", err)
+	}
+	if err := e.open(); err != nil {
+		return err
+	}
+	backups, err := e.service.SelectBackups(sel)
+	if err != nil {
+		return err
+	}
+	if out.format != FormatText {
+		return out.write(e.stdout, backups, false)
+	}
+
+	tw := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tCREATED\tSIZE\tTAGS")
+	for _, b := range backups {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", b.ID, b.Name, b.CreatedAt.Format("2006-01-02 15:04:05"),
+			components.FormatSize(b.Size), strings.Join(b.Tags, ","))
+	}
+	return tw.Flush()
+}
+
+func runRestore(e *env, fs *flag.FlagSet, args []string) error {
+	terms, err := parseSelector(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if err := e.open(); err != nil {
+		return err
+	}
+	found, err := e.find(terms, true)
+	if err != nil {
+		return err
+	}
+
+	if err := e.service.RestoreBackupWithAutoBackup(found[0]); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "Restored backup %d: %s\n", found[0].ID, found[0].Name)
+	return nil
+}
+
+func runDelete(e *env, fs *flag.FlagSet, args []string) error {
+	terms, err := parseSelector(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if err := e.open(); err != nil {
+		return err
+	}
+	found, err := e.find(terms, false)
+	if err != nil {
+		return err
+	}
+
+	if err := e.service.DeleteBackups(found); err != nil {
+		return err
+	}
+	for _, b := range found {
+		fmt.Fprintf(e.stdout, "Moved backup %d to the trash: %s\n", b.ID, b.Name)
+	}
+	return nil
+}
+
+func runInfo(e *env, fs *flag.FlagSet, args []string) error {
+	out := addOutputFlags(fs)
+	terms, err := parseSelector(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if err := out.check(); err != nil {
+		return err
+	}
+	if err := e.open(); err != nil {
+		return err
+	}
+	found, err := e.find(terms, true)
+	if err != nil {
+		return err
+	}
+	if out.format != FormatText {
+		return out.write(e.stdout, found, true)
+	}
+	b := found[0]
+
+	tw := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+	for _, field := range [][2]string{
+		{"ID", strconv.Itoa(b.ID)},
+		{"Name", b.Name},
+		{"Game", b.GameID},
+		{"Created", b.CreatedAt.Format("2006-01-02 15:04:05")},
+		{"Size", components.FormatSize(b.Size)},
+		{"Kind", b.Kind},
+		{"Compression", b.Codec},
+		{"SHA-256", b.Hash},
+		{"Stored at", b.Path},
+		{"Note", b.Note},
+		{"Tags", strings.Join(b.Tags, ", ")},
+	} {
+		fmt.Fprintf(tw, "%s:\t%s\n", field[0], field[1])
+	}
+	return tw.Flush()
+}
+
+func runExport(e *env, fs *flag.FlagSet, args []string) error {
+	file := fs.String("o", "", "bundle file to write (default <game>-backups-<time>.tar)")
+	terms, err := parseSelector(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if err := e.open(); err != nil {
+		return err
+	}
+	found, err := e.find(terms, false)
+	if err != nil {
+		return err
+	}
+
+	path := *file
+	if path == "" {
+		path = backup.BundleName(found[0].GameID, time.Now())
+	}
+	if err := e.service.ExportBackups(path, found); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "Exported %d backup(s) to %s\n", len(found), path)
+	return nil
+}
+
+func runImport(e *env, fs *flag.FlagSet, args []string) error {
+	onCollision := fs.String("on-collision", backup.CollisionRename, "what to do when a backup's name is taken: rename or skip")
+	game := fs.String("game", "", "import every backup into this game instead of matching games by ID or name")
+	rest, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if *onCollision != backup.CollisionRename && *onCollision != backup.CollisionSkip {
+		return usagef("--on-collision must be rename or skip, not %q", *onCollision)
+	}
+	if err := e.open(); err != nil {
+		return err
+	}
+
+	results, err := e.service.ImportBundle(rest[0], *onCollision, *game)
+	unmatched := 0
+	for _, r := range results {
+		switch r.Status {
+		case backup.ImportRenamed:
+			fmt.Fprintf(e.stdout, "%s: %s as %q into %s\n", r.Backup.Name, r.Status, r.Name, r.GameID)
+		case backup.ImportAdded:
+			fmt.Fprintf(e.stdout, "%s: %s into %s\n", r.Backup.Name, r.Status, r.GameID)
+		case backup.ImportNoGame:
+			unmatched++
+			fmt.Fprintf(e.stdout, "%s: %s for %q\n", r.Backup.Name, r.Status, bundledGame(r.Backup.Game))
+		default:
+			fmt.Fprintf(e.stdout, "%s: %s\n", r.Backup.Name, r.Status)
+		}
+	}
+	if err == nil && unmatched > 0 {
+		fmt.Fprintf(e.stdout, "%d backup(s) were skipped because no game matches theirs; use --game to choose one\n", unmatched)
+	}
+	return err
+}
+
+// bundledGame names the game a bundled backup was exported from.
+func bundledGame(g backup.BundleGame) string {
+	if g.Name == "" {
+		return g.ID
+	}
+	return g.Name
+}
+
+func runDoctor(e *env, fs *flag.FlagSet, args []string) error {
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	report := doctor.Run(doctor.Options{Config: e.cfg, Location: e.setup.Location, LoadErr: e.setup.LoadErr})
+
+	tw := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+	for _, c := range report.Checks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Status, c.Name, c.Detail)
+		if c.Hint != "" {
+			fmt.Fprintf(tw, "\t\tfix: %s\n", c.Hint)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(e.stdout, "\n%d OK, %d warning(s), %d failed, %d skipped\n", report.Count(doctor.StatusOK),
+		report.Count(doctor.StatusWarning), report.Count(doctor.StatusFailed), report.Count(doctor.StatusSkipped))
+	if !report.Healthy() {
+		return fmt.Errorf("%d check(s) failed", report.Count(doctor.StatusFailed))
+	}
+	return nil
+}
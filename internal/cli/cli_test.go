@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/config"
+)
+
+// testSetup returns a configuration for one game, hades, whose save is a
+// single file, with no backups yet.
+func testSetup(t *testing.T) Setup {
+	t.Helper()
+	dir := t.TempDir()
+	save := filepath.Join(dir, "hades.sav")
+	if err := os.WriteFile(save, []byte("hades"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{BackupDir: filepath.Join(dir, "backups")}
+	cfg.AddGame("Hades", save)
+	return Setup{Config: cfg, Location: config.Location{Path: filepath.Join(dir, "config.json"), Source: config.SourceFlag}}
+}
+
+// run runs a command and returns its exit code and output.
+func run(setup Setup, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := Run(setup, args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// mustRun runs a command that is expected to succeed.
+func mustRun(t *testing.T, setup Setup, args ...string) string {
+	t.Helper()
+	code, stdout, stderr := run(setup, args...)
+	if code != ExitOK {
+		t.Fatalf("%v exited with %d: %s", args, code, stderr)
+	}
+	return stdout
+}
+
+func TestExitCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(s *Setup)
+		args   []string
+		code   int
+		stderr string // part of what is written to stderr
+	}{
+		{"success", nil, []string{"list"}, ExitOK, ""},
+		{"help", nil, []string{"list", "-h"}, ExitOK, "Usage: list [selector]"},
+		{"unknown command", nil, []string{"backup"}, ExitUsage, `unknown command "backup"`},
+		{"unknown flag", nil, []string{"create", "--force"}, ExitUsage, "flag provided but not defined: -force"},
+		{"too many arguments", nil, []string{"create", "a", "b"}, ExitUsage, "too many arguments"},
+		{"missing selector", nil, []string{"restore"}, ExitUsage, "missing selector"},
+		{"bad selector", nil, []string{"info", "after:someday"}, ExitUsage, "info: "},
+		{"bad format", nil, []string{"list", "--format", "yaml"}, ExitUsage, `unknown format "yaml"`},
+		{"bad collision policy", nil, []string{"import", "--on-collision", "replace", "b.tar"}, ExitUsage, "--on-collision must be rename or skip"},
+		{"no match", nil, []string{"info", "name:boss"}, ExitNotFound, `no backup matches "name:boss"`},
+		{"more than one match", nil, []string{"restore", "tag:daily"}, ExitError, "matches 2 backups"},
+		{"operation fails", nil, []string{"import", "missing.tar"}, ExitError, "import: "},
+		{"config cannot be loaded", func(s *Setup) { s.LoadErr = errors.New("bad json") }, []string{"list"}, ExitError, "Run the doctor command"},
+		{"doctor reports a bad config", func(s *Setup) { s.LoadErr = errors.New("bad json") }, []string{"doctor"}, ExitError, "check(s) failed"},
+		{"no config file", func(s *Setup) { s.FirstRun, s.Config = true, &config.Config{} }, []string{"list"}, ExitError, "no configuration found"},
+		{"no config file, but overrides", func(s *Setup) { s.FirstRun = true }, []string{"list"}, ExitOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := testSetup(t)
+			mustRun(t, setup, "create", "--tags", "daily", "first")
+			mustRun(t, setup, "create", "--tags", "daily", "second")
+			if tt.setup != nil {
+				tt.setup(&setup)
+			}
+
+			code, _, stderr := run(setup, tt.args...)
+			if code != tt.code {
+				t.Errorf("%v exited with %d, want %d; stderr: %s", tt.args, code, tt.code, stderr)
+			}
+			if !strings.Contains(stderr, tt.stderr) {
+				t.Errorf("%v wrote %q to stderr, want it to contain %q", tt.args, stderr, tt.stderr)
+			}
+		})
+	}
+}
+
+func TestCommands(t *testing.T) {
+	setup := testSetup(t)
+	save := setup.Config.Games[0].SavePath
+
+	if out := mustRun(t, setup, "create", "--note", "before the boss", "first"); out != "Created backup 1: first\n" {
+		t.Errorf("create wrote %q", out)
+	}
+	if err := os.WriteFile(save, []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if out := mustRun(t, setup, "restore", "first"); out != "Restored backup 1: first\n" {
+		t.Errorf("restore wrote %q", out)
+	}
+	if data, _ := os.ReadFile(save); string(data) != "hades" {
+		t.Errorf("save = %q after restoring, want %q", data, "hades")
+	}
+
+	bundle := filepath.Join(t.TempDir(), "hades.tar")
+	if out := mustRun(t, setup, "export", "-o", bundle, "name:first"); !strings.Contains(out, "Exported 1 backup(s)") {
+		t.Errorf("export wrote %q", out)
+	}
+	if out := mustRun(t, setup, "delete", "-tag:auto", "name:first"); out != "Moved backup 1 to the trash: first\n" {
+		t.Errorf("delete wrote %q", out)
+	}
+	if out := mustRun(t, setup, "import", bundle); !strings.Contains(out, "first: ") {
+		t.Errorf("import wrote %q", out)
+	}
+	if out := mustRun(t, setup, "info", "latest"); !strings.Contains(out, "Note:") || !strings.Contains(out, "before the boss") {
+		t.Errorf("info wrote %q, want the imported backup with its note", out)
+	}
+}
@@ -0,0 +1,204 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/backup"
+)
+
+// SchemaVersion is the version of the Record layout written by the json,
+// ndjson and csv formats. Fields may be added without changing it; it is
+// only raised when a field is removed, renamed or changes meaning.
+const SchemaVersion = 1
+
+// Output formats.
+const (
+	FormatText     = "text"
+	FormatJSON     = "json"
+	FormatNDJSON   = "ndjson"
+	FormatCSV      = "csv"
+	FormatTemplate = "template"
+)
+
+// Record is a backup as written by the machine-readable formats.
+type Record struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Game      string    `json:"game"`
+	Path      string    `json:"path"` // where the backup's contents are stored
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size"` // bytes
+	Hash      string    `json:"hash"` // SHA-256
+	Tags      []string  `json:"tags"`
+	Note      string    `json:"note"`
+	Kind      string    `json:"kind"`        // "blob" for a file, "tree" for a directory
+	Codec     string    `json:"compression"` // "none", "gzip" or "zstd"
+}
+
+// NewRecord converts a backup into a Record.
+func NewRecord(b backup.Backup) Record {
+	tags := b.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return Record{
+		ID:        b.ID,
+		Name:      b.Name,
+		Game:      b.GameID,
+		Path:      b.Path,
+		CreatedAt: b.CreatedAt,
+		Size:      b.Size,
+		Hash:      b.Hash,
+		Tags:      tags,
+		Note:      b.Note,
+		Kind:      b.Kind,
+		Codec:     b.Codec,
+	}
+}
+
+// csvHeader lists the csv columns in order. New columns are only ever
+// added at the end.
+var csvHeader = []string{"schema_version", "id", "name", "game", "path", "created_at", "size", "hash", "tags", "note", "kind", "compression"}
+
+func (r Record) csvRow() []string {
+	return []string{
+		strconv.Itoa(SchemaVersion),
+		strconv.Itoa(r.ID),
+		r.Name,
+		r.Game,
+		r.Path,
+		r.CreatedAt.Format(time.RFC3339),
+		strconv.FormatInt(r.Size, 10),
+		r.Hash,
+		strings.Join(r.Tags, ","),
+		r.Note,
+		r.Kind,
+		r.Codec,
+	}
+}
+
+// output holds the --format and --template flags of a command.
+type output struct {
+	format   string
+	template string
+	tmpl     *template.Template
+}
+
+// addOutputFlags adds --format and --template to a command.
+func addOutputFlags(fs *flag.FlagSet) *output {
+	o := &output{}
+	fs.StringVar(&o.format, "format", FormatText, "output format: text, json, ndjson, csv or template")
+	fs.StringVar(&o.template, "template", "", "Go text/template applied to each backup, e.g. '{{.ID}} {{.Name}}'; implies --format template")
+	return o
+}
+
+// check validates the flags once they have been parsed.
+func (o *output) check() error {
+	if o.template != "" {
+		if o.format != FormatText && o.format != FormatTemplate {
+			return usagef("--template cannot be combined with --format %s", o.format)
+		}
+		o.format = FormatTemplate
+	}
+	switch o.format {
+	case FormatText, FormatJSON, FormatNDJSON, FormatCSV:
+	case FormatTemplate:
+		if o.template == "" {
+			return usagef("--format template needs --template")
+		}
+		tmpl, err := template.New("backup").Funcs(templateFuncs).Parse(o.template)
+		if err != nil {
+			return usagef("invalid template: %v", err)
+		}
+		o.tmpl = tmpl
+	default:
+		return usagef("unknown format %q", o.format)
+	}
+	return nil
+}
+
+// listDocument is the json output of a list of backups.
+type listDocument struct {
+	SchemaVersion int      `json:"schema_version"`
+	Backups       []Record `json:"backups"`
+}
+
+// backupDocument is the json output of a single backup.
+type backupDocument struct {
+	SchemaVersion int    `json:"schema_version"`
+	Backup        Record `json:"backup"`
+}
+
+// ndjsonLine is one line of ndjson output.
+type ndjsonLine struct {
+	SchemaVersion int `json:"schema_version"`
+	Record
+}
+
+// write writes backups in the machine-readable format chosen. single is set
+// when a command shows one backup, which json writes as an object rather
+// than a list. The text format is left to the command.
+func (o *output) write(w io.Writer, backups []backup.Backup, single bool) error {
+	records := make([]Record, len(backups))
+	for i, b := range backups {
+		records[i] = NewRecord(b)
+	}
+
+	switch o.format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if single {
+			return enc.Encode(backupDocument{SchemaVersion: SchemaVersion, Backup: records[0]})
+		}
+		return enc.Encode(listDocument{SchemaVersion: SchemaVersion, Backups: records})
+
+	case FormatNDJSON:
+		enc := json.NewEncoder(w)
+		for _, r := range records {
+			if err := enc.Encode(ndjsonLine{SchemaVersion: SchemaVersion, Record: r}); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		cw.Write(csvHeader)
+		for _, r := range records {
+			cw.Write(r.csvRow())
+		}
+		cw.Flush()
+		return cw.Error()
+
+	case FormatTemplate:
+		for _, r := range records {
+			if err := o.tmpl.Execute(w, r); err != nil {
+				return err
+			}
+			if !strings.HasSuffix(o.template, "\n") {
+				fmt.Fprintln(w)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("format %q is not machine-readable", o.format)
+}
+
+// templateFuncs are available to --template in addition to the built-ins.
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"time": func(layout string, t time.Time) string { return t.Format(layout) },
+}
@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/backup"
+)
+
+var testBackups = []backup.Backup{
+	{ID: 2, Name: "boss, \"final\"", GameID: "hades", Path: "/b/objects/ab/cd", Size: 2048, Hash: "abcd",
+		CreatedAt: time.Date(2024, 5, 2, 10, 30, 0, 0, time.UTC), Tags: []string{"boss", "milestone"},
+		Note: "before the boss", Kind: backup.KindTree, Codec: backup.CodecZstd},
+	{ID: 1, Name: "first", GameID: "hades", Path: "/b/first.sav", Size: 5, Hash: "ef01",
+		CreatedAt: time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC), Kind: backup.KindBlob, Codec: backup.CodecNone},
+}
+
+func TestOutputFormats(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		single bool
+		want   string
+	}{
+		{"json list", []string{"--format", "json"}, false, `{
+  "schema_version": 1,
+  "backups": [
+    {
+      "id": 2,
+      "name": "boss, \"final\"",
+      "game": "hades",
+      "path": "/b/objects/ab/cd",
+      "created_at": "2024-05-02T10:30:00Z",
+      "size": 2048,
+      "hash": "abcd",
+      "tags": [
+        "boss",
+        "milestone"
+      ],
+      "note": "before the boss",
+      "kind": "tree",
+      "compression": "zstd"
+    },
+    {
+      "id": 1,
+      "name": "first",
+      "game": "hades",
+      "path": "/b/first.sav",
+      "created_at": "2024-05-01T09:00:00Z",
+      "size": 5,
+      "hash": "ef01",
+      "tags": [],
+      "note": "",
+      "kind": "blob",
+      "compression": "none"
+    }
+  ]
+}
+`},
+		{"json single", []string{"--format", "json"}, true, `{
+  "schema_version": 1,
+  "backup": {
+    "id": 2,
+    "name": "boss, \"final\"",
+    "game": "hades",
+    "path": "/b/objects/ab/cd",
+    "created_at": "2024-05-02T10:30:00Z",
+    "size": 2048,
+    "hash": "abcd",
+    "tags": [
+      "boss",
+      "milestone"
+    ],
+    "note": "before the boss",
+    "kind": "tree",
+    "compression": "zstd"
+  }
+}
+`},
+		{"ndjson", []string{"--format", "ndjson"}, false,
+			`{"schema_version":1,"id":2,"name":"boss, \"final\"","game":"hades","path":"/b/objects/ab/cd","created_at":"2024-05-02T10:30:00Z","size":2048,"hash":"abcd","tags":["boss","milestone"],"note":"before the boss","kind":"tree","compression":"zstd"}
+{"schema_version":1,"id":1,"name":"first","game":"hades","path":"/b/first.sav","created_at":"2024-05-01T09:00:00Z","size":5,"hash":"ef01","tags":[],"note":"","kind":"blob","compression":"none"}
+`},
+		{"csv", []string{"--format", "csv"}, false, `schema_version,id,name,game,path,created_at,size,hash,tags,note,kind,compression
+1,2,"boss, ""final""",hades,/b/objects/ab/cd,2024-05-02T10:30:00Z,2048,abcd,"boss,milestone",before the boss,tree,zstd
+1,1,first,hades,/b/first.sav,2024-05-01T09:00:00Z,5,ef01,,,blob,none
+`},
+		{"template", []string{"--template", "{{.ID}} {{.Name}} {{join .Tags \"+\"}}"}, false, "2 boss, \"final\" boss+milestone\n1 first \n"},
+		{"template with newline", []string{"--format", "template", "--template", "{{.ID}}\n"}, false, "2\n1\n"},
+		{"template functions", []string{"--template", `{{time "2006-01-02" .CreatedAt}} {{json .Tags}}`}, false,
+			"2024-05-02 [\"boss\",\"milestone\"]\n2024-05-01 []\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := parseOutput(t, tt.args)
+			if err := out.check(); err != nil {
+				t.Fatalf("check() error = %v", err)
+			}
+			backups := testBackups
+			if tt.single {
+				backups = backups[:1]
+			}
+
+			var buf bytes.Buffer
+			if err := out.write(&buf, backups, tt.single); err != nil {
+				t.Fatalf("write() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("write() =\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestOutputFlagErrors(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--format", "yaml"}, `unknown format "yaml"`},
+		{[]string{"--format", "template"}, "--format template needs --template"},
+		{[]string{"--format", "json", "--template", "{{.ID}}"}, "--template cannot be combined with --format json"},
+		{[]string{"--template", "{{.ID"}, "invalid template"},
+	}
+	for _, tt := range tests {
+		err := parseOutput(t, tt.args).check()
+		var usage usageError
+		if !errors.As(err, &usage) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("check() with %v error = %v, want a usage error containing %q", tt.args, err, tt.want)
+		}
+	}
+}
+
+func TestListFormats(t *testing.T) {
+	setup := testSetup(t)
+	mustRun(t, setup, "create", "--tags", "boss", "first")
+	mustRun(t, setup, "create", "second")
+
+	var doc listDocument
+	if err := json.Unmarshal([]byte(mustRun(t, setup, "list", "--format", "json")), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.SchemaVersion != SchemaVersion || len(doc.Backups) != 2 || doc.Backups[0].Name != "second" {
+		t.Errorf("list --format json = %+v, want both backups, newest first", doc)
+	}
+
+	var one backupDocument
+	if err := json.Unmarshal([]byte(mustRun(t, setup, "info", "--format", "json", "tag:boss")), &one); err != nil {
+		t.Fatal(err)
+	}
+	if one.Backup.Name != "first" || one.Backup.Game != "hades" || len(one.Backup.Tags) != 1 {
+		t.Errorf("info --format json = %+v, want the tagged backup", one)
+	}
+
+	if out := mustRun(t, setup, "list", "--template", "{{.Name}}", "-tag:boss"); out != "second\n" {
+		t.Errorf("list --template = %q, want %q", out, "second\n")
+	}
+}
+
+func parseOutput(t *testing.T, args []string) *output {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	out := addOutputFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
@@ -3,20 +3,138 @@ package components
 import (
 	"fmt"
 	"io"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/backup"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/config"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/discovery"
 	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/layout"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/retention"
 	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/tui"
 )
 
 // ListItem wraps backup.Backup to implement list.Item interface
 type ListItem backup.Backup
 
-func (i ListItem) Title() string       { return i.Name }
-func (i ListItem) Description() string { return i.CreatedAt.Format("2006-01-02 15:04:05") }
-func (i ListItem) FilterValue() string { return i.Name }
+func (i ListItem) Title() string { return i.Name }
+func (i ListItem) Description() string {
+	desc := i.CreatedAt.Format("2006-01-02 15:04:05")
+	if i.Note != "" {
+		desc += "  " + i.Note
+	}
+	if len(i.Tags) > 0 {
+		desc += "  [" + strings.Join(i.Tags, "] [") + "]"
+	}
+	return desc
+}
+
+// FilterValue includes notes and tags so the list filter matches on them too
+func (i ListItem) FilterValue() string {
+	return strings.Join(append([]string{i.Name, i.Note}, i.Tags...), " ")
+}
+
+// TrashItem wraps a backup in the trash to implement list.Item interface
+type TrashItem struct {
+	Backup  backup.Backup
+	PurgeAt time.Time
+}
+
+func (i TrashItem) Title() string { return i.Backup.Name }
+func (i TrashItem) Description() string {
+	return fmt.Sprintf("created %s, deleted %s, purged after %s",
+		i.Backup.CreatedAt.Format("2006-01-02 15:04"),
+		i.Backup.DeletedAt.Format("2006-01-02 15:04"),
+		i.PurgeAt.Format("2006-01-02"))
+}
+func (i TrashItem) FilterValue() string { return ListItem(i.Backup).FilterValue() }
+
+// GameItem wraps a game profile to implement list.Item interface
+type GameItem struct {
+	Game   config.Game
+	Active bool
+}
+
+func (i GameItem) Title() string {
+	if i.Active {
+		return i.Game.Name + " (active)"
+	}
+	return i.Game.Name
+}
+func (i GameItem) Description() string { return i.Game.SavePath }
+func (i GameItem) FilterValue() string { return i.Game.Name }
+
+// PreviewItem wraps a file from a filter preview to implement list.Item interface
+type PreviewItem struct {
+	File     backup.PreviewFile
+	Included bool
+}
+
+func (i PreviewItem) Title() string { return i.File.Path }
+func (i PreviewItem) Description() string {
+	if i.Included {
+		return fmt.Sprintf("included, %s", FormatSize(i.File.Size))
+	}
+	return fmt.Sprintf("excluded, %s", FormatSize(i.File.Size))
+}
+func (i PreviewItem) FilterValue() string { return i.File.Path }
+
+// CandidateItem wraps a discovered game to implement list.Item interface
+type CandidateItem discovery.Candidate
+
+func (i CandidateItem) Title() string       { return i.Name }
+func (i CandidateItem) Description() string { return i.Source + ", " + i.SavePath }
+func (i CandidateItem) FilterValue() string { return i.Name }
+
+// ProblemItem wraps a configuration problem to implement list.Item interface
+type ProblemItem struct {
+	Problem config.FieldError
+	Game    string // name of the game the field belongs to, if any
+}
+
+func (i ProblemItem) Title() string {
+	if i.Game != "" {
+		return i.Problem.Path() + " (" + i.Game + ")"
+	}
+	return i.Problem.Path()
+}
+func (i ProblemItem) Description() string { return i.Problem.Message }
+func (i ProblemItem) FilterValue() string { return i.Title() }
+
+// BackupOf returns the backup behind a list item, if it has one
+func BackupOf(item list.Item) (backup.Backup, bool) {
+	switch i := item.(type) {
+	case ListItem:
+		return backup.Backup(i), true
+	case TrashItem:
+		return i.Backup, true
+	}
+	return backup.Backup{}, false
+}
+
+// VerifyItem wraps backup.VerifyResult to implement list.Item interface
+type VerifyItem backup.VerifyResult
+
+func (i VerifyItem) Title() string { return i.Backup.Name }
+func (i VerifyItem) Description() string {
+	return fmt.Sprintf("[%s] %s", i.Status, i.Detail)
+}
+func (i VerifyItem) FilterValue() string { return i.Backup.Name + " " + i.Status.String() }
+
+// PruneItem wraps retention.Decision to implement list.Item interface
+type PruneItem retention.Decision
+
+func (i PruneItem) Title() string { return i.Backup.Name }
+func (i PruneItem) Description() string {
+	created := i.Backup.CreatedAt.Format("2006-01-02 15:04:05")
+	if !i.Keep {
+		return created + "  [remove]"
+	}
+	return created + "  [keep: " + strings.Join(i.Reasons, ", ") + "]"
+}
+func (i PruneItem) FilterValue() string { return i.Backup.Name }
 
 // NormalItemDelegate handles rendering for normal list views (no checkboxes)
 type NormalItemDelegate struct {
@@ -41,7 +159,7 @@ func NewNormalItemDelegate() *NormalItemDelegate {
 
 // Render method for normal list items (no checkboxes)
 func (d *NormalItemDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
-	i, ok := item.(ListItem)
+	i, ok := item.(list.DefaultItem)
 	if !ok {
 		return
 	}
@@ -72,7 +190,7 @@ func (d *NormalItemDelegate) Render(w io.Writer, m list.Model, index int, item l
 // SelectableItemDelegate handles rendering for delete view (with checkboxes)
 type SelectableItemDelegate struct {
 	list.DefaultDelegate
-	selected map[int]struct{}
+	selected map[int]struct{} // IDs of the ticked backups
 }
 
 // NewSelectableItemDelegate creates a delegate for delete view with checkboxes
@@ -95,16 +213,16 @@ func NewSelectableItemDelegate(selected map[int]struct{}) *SelectableItemDelegat
 
 // Render method for selectable list items (with checkboxes)
 func (d *SelectableItemDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
-	i, ok := item.(ListItem)
+	i, ok := item.(list.DefaultItem)
 	if !ok {
 		return
 	}
 
-	var checkbox string
-	if _, ok := d.selected[index]; ok {
-		checkbox = "☑"
-	} else {
-		checkbox = "☐"
+	checkbox := "☐"
+	if b, ok := BackupOf(item); ok {
+		if _, ok := d.selected[b.ID]; ok {
+			checkbox = "☑"
+		}
 	}
 
 	title := i.Title()
@@ -142,4 +260,18 @@ func generateSpaces(count int) string {
 		spaces += " "
 	}
 	return spaces
-}
\ No newline at end of file
+}
+
+// FormatSize renders a byte count using binary units
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -2,62 +2,144 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/paths"
 )
 
 // Config holds the application's configuration.
 type Config struct {
-	SavePath   string `json:"save_path"`
-	BackupDir  string `json:"backup_dir"`
-	AutoBackup bool   `json:"auto_backup"`
+	// Version is the format of the file; see ConfigVersion. Older files are
+	// upgraded when loaded.
+	Version int `json:"version"`
+
+	// BackupDir holds the backup database and the object store shared by
+	// all games. It is stored as typed; see ResolveBackupDir.
+	BackupDir string `json:"backup_dir"`
+
+	// Games lists the configured game profiles, and ActiveGame is the ID of
+	// the one the application works on.
+	Games      []Game `json:"games"`
+	ActiveGame string `json:"active_game,omitempty"`
+
+	// Compression is the codec used for new backups: "none", "gzip" or "zstd".
+	Compression string `json:"compression,omitempty"`
+	// CompressionLevel is codec-specific; 0 selects the codec's default.
+	CompressionLevel int `json:"compression_level,omitempty"`
+
+	// Retention controls which backups are kept when pruning.
+	Retention Retention `json:"retention"`
+
+	// TrashDays is how long deleted backups stay in the trash before they
+	// are purged. 0 uses DefaultTrashDays.
+	TrashDays int `json:"trash_days,omitempty"`
+
+	// ReconcileOnStartup checks the database against the backup directory
+	// when the application starts.
+	ReconcileOnStartup bool `json:"reconcile_on_startup,omitempty"`
+
+	// Manifest is the save-location manifest last imported, offered again
+	// the next time one is imported.
+	Manifest string `json:"manifest,omitempty"`
+
+	// path is the file the configuration was loaded from and is saved to.
+	path string
+	// overrides are the settings given outside the file; see ApplyOverrides.
+	overrides []applied
+	// overrideGame is the ID of the game ApplyOverrides made up for a save
+	// path when the file has no games.
+	overrideGame string
 }
 
-// Load loads the configuration from a file. If the file doesn't exist,
-// it returns a default configuration and a 'first run' flag.
-func Load() (*Config, bool, error) {
-	configPath, err := getConfigPath()
-	if err != nil {
-		return nil, false, err
+// ResolveBackupDir returns BackupDir with ~, environment variables and
+// placeholders expanded.
+func (c *Config) ResolveBackupDir() (string, error) {
+	return paths.Expand(c.BackupDir, paths.Options{})
+}
+
+// DefaultTrashDays is how long deleted backups are kept when TrashDays is not set.
+const DefaultTrashDays = 30
+
+// TrashRetentionDays returns how many days deleted backups stay in the trash.
+func (c *Config) TrashRetentionDays() int {
+	if c.TrashDays <= 0 {
+		return DefaultTrashDays
 	}
+	return c.TrashDays
+}
+
+// Retention describes a grandfather-father-son retention policy. Each rule
+// keeps backups independently; a backup is kept if any rule selects it.
+// A zero value for a rule disables it.
+type Retention struct {
+	KeepLast    int `json:"keep_last"`    // keep the N most recent backups
+	KeepDaily   int `json:"keep_daily"`   // keep the newest backup of each of the last D days
+	KeepWeekly  int `json:"keep_weekly"`  // keep the newest backup of each of the last W weeks
+	KeepMonthly int `json:"keep_monthly"` // keep the newest backup of each of the last M months
+}
 
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return &Config{AutoBackup: false}, true, nil
+// IsEmpty returns true if no retention rule is enabled.
+func (r Retention) IsEmpty() bool {
+	return r.KeepLast <= 0 && r.KeepDaily <= 0 && r.KeepWeekly <= 0 && r.KeepMonthly <= 0
+}
+
+// Load loads the configuration from the file at path; see Locate. If the
+// file doesn't exist, it returns a default configuration and a 'first run'
+// flag. Files in an older format are upgraded and saved, keeping a copy of
+// the original.
+func Load(path string) (*Config, bool, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return &Config{Version: ConfigVersion, path: path}, true, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, err
 	}
 
-	data, err := os.ReadFile(configPath)
+	data, upgraded, err := upgrade(path, data)
 	if err != nil {
 		return nil, false, err
 	}
 
 	var cfg Config
 	if err := json.Unmarshal(data, &cfg); err != nil {
-		return nil, false, err
+		return nil, false, fmt.Errorf("%s: %v", path, err)
 	}
+	cfg.path = path
+	cfg.normalizeGames()
 
+	if upgraded {
+		if err := cfg.Save(); err != nil {
+			return nil, false, fmt.Errorf("failed to save upgraded config: %v", err)
+		}
+	}
 	return &cfg, false, nil
 }
 
-// Save saves the configuration to a file.
+// Path returns the file the configuration is saved to.
+func (c *Config) Path() string {
+	return c.path
+}
+
+// Save saves the configuration to the file it was loaded from, creating its
+// directory if needed. Values overridden from the environment or command
+// line are saved as they were in the file.
 func (c *Config) Save() error {
-	configPath, err := getConfigPath()
-	if err != nil {
-		return err
+	if c.path == "" {
+		return fmt.Errorf("configuration file location is not set")
 	}
+	c.Version = ConfigVersion
 
-	data, err := json.MarshalIndent(c, "", "  ")
+	data, err := json.MarshalIndent(c.forFile(), "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(configPath, data, 0644)
-}
-
-// getConfigPath returns the path to the configuration file.
-func getConfigPath() (string, error) {
-	exePath, err := os.Executable()
-	if err != nil {
-		return "", err
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
 	}
-	return filepath.Join(filepath.Dir(exePath), "config.json"), nil
+	return os.WriteFile(c.path, data, 0644)
 }
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/paths"
+)
+
+// Game is a profile for one game: where its saves live and how they are backed up.
+type Game struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+
+	// SavePath is the save file or directory. Like Prefix, it may use ~,
+	// environment variables and placeholders; see ResolveSavePath.
+	SavePath string `json:"save_path"`
+	// Prefix is the Wine or Proton prefix that <prefix> in SavePath refers to.
+	Prefix string `json:"prefix,omitempty"`
+
+	// AutoBackup creates a backup of the current save before restoring another.
+	AutoBackup bool `json:"auto_backup"`
+
+	// Include and Exclude are doublestar glob patterns, relative to a save
+	// directory, that pick which files are backed up. With no Include
+	// patterns every file is included.
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+
+	// Compression, CompressionLevel and Retention override the global
+	// settings for this game when set.
+	Compression      string     `json:"compression,omitempty"`
+	CompressionLevel int        `json:"compression_level,omitempty"`
+	Retention        *Retention `json:"retention,omitempty"`
+}
+
+// DefaultGameName names the game created from a config that predates
+// profiles, during first-run setup, or for a save path override when no
+// game is configured.
+const DefaultGameName = "Default"
+
+// normalizeGames fills in missing names and IDs and makes sure ActiveGame
+// is valid.
+func (c *Config) normalizeGames() {
+	for i := range c.Games {
+		g := &c.Games[i]
+		if g.Name == "" {
+			g.Name = fmt.Sprintf("Game %d", i+1)
+		}
+		if g.ID == "" {
+			g.ID = c.uniqueGameID(g.Name)
+		}
+	}
+
+	if c.FindGame(c.ActiveGame) == nil {
+		c.ActiveGame = ""
+		if len(c.Games) > 0 {
+			c.ActiveGame = c.Games[0].ID
+		}
+	}
+}
+
+// FindGame returns the game with the given ID, or nil if there is none.
+func (c *Config) FindGame(id string) *Game {
+	for i := range c.Games {
+		if c.Games[i].ID == id {
+			return &c.Games[i]
+		}
+	}
+	return nil
+}
+
+// LookupGame finds a game by a loosely typed reference: its ID, or its ID or
+// name ignoring case and punctuation, so "eldenring" finds "elden-ring".
+// It returns nil if no game or more than one game matches.
+func (c *Config) LookupGame(ref string) *Game {
+	if g := c.FindGame(ref); g != nil {
+		return g
+	}
+	key := strings.ReplaceAll(slugify(ref), "-", "")
+	if key == "" {
+		return nil
+	}
+	var found *Game
+	for i := range c.Games {
+		g := &c.Games[i]
+		if strings.ReplaceAll(slugify(g.ID), "-", "") == key || strings.ReplaceAll(slugify(g.Name), "-", "") == key {
+			if found != nil && found != g {
+				return nil
+			}
+			found = g
+		}
+	}
+	return found
+}
+
+// ActiveGameProfile returns the game the application is working on, or nil
+// if no game is configured.
+func (c *Config) ActiveGameProfile() *Game {
+	if g := c.FindGame(c.ActiveGame); g != nil {
+		return g
+	}
+	if len(c.Games) > 0 {
+		return &c.Games[0]
+	}
+	return nil
+}
+
+// SetActiveGame switches to the game with the given ID. It returns false if
+// there is no such game.
+func (c *Config) SetActiveGame(id string) bool {
+	if c.FindGame(id) == nil {
+		return false
+	}
+	c.ActiveGame = id
+	return true
+}
+
+// AddGame adds a game profile with a unique ID and returns it.
+func (c *Config) AddGame(name, savePath string) *Game {
+	id := c.uniqueGameID(name)
+	c.Games = append(c.Games, Game{
+		ID:       id,
+		Name:     name,
+		SavePath: savePath,
+	})
+	if c.ActiveGame == "" {
+		c.ActiveGame = id
+	}
+	return &c.Games[len(c.Games)-1]
+}
+
+// Complete reports whether the backup directory and the active game's save
+// path are set, which is all a command needs to run. Overrides can make up
+// for a config file that doesn't exist yet.
+func (c *Config) Complete() bool {
+	g := c.ActiveGameProfile()
+	return c.BackupDir != "" && g != nil && g.SavePath != ""
+}
+
+// OwnsLegacyBackups reports whether g is the first game, which backups made
+// before game profiles existed belong to. Those were .sav files written to
+// BackupDir itself.
+func (c *Config) OwnsLegacyBackups(g *Game) bool {
+	return len(c.Games) > 0 && g != nil && c.Games[0].ID == g.ID
+}
+
+// ResolveSavePath returns the game's save path with ~, environment variables
+// and placeholders expanded.
+func (g *Game) ResolveSavePath() (string, error) {
+	return paths.Expand(g.SavePath, paths.Options{Prefix: g.Prefix})
+}
+
+// CompressionFor returns the codec and level to use for new backups of g.
+func (c *Config) CompressionFor(g *Game) (string, int) {
+	if g != nil && g.Compression != "" {
+		return g.Compression, g.CompressionLevel
+	}
+	return c.Compression, c.CompressionLevel
+}
+
+// RetentionFor returns the retention policy that applies to g.
+func (c *Config) RetentionFor(g *Game) Retention {
+	if g != nil && g.Retention != nil {
+		return *g.Retention
+	}
+	return c.Retention
+}
+
+// uniqueGameID derives an ID from name that no configured game uses yet.
+func (c *Config) uniqueGameID(name string) string {
+	base := slugify(name)
+	if base == "" {
+		base = "game"
+	}
+	id := base
+	for n := 2; c.FindGame(id) != nil; n++ {
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+	return id
+}
+
+// slugify turns a game name into a lowercase ID made of letters, digits and dashes.
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/paths"
+)
+
+// AppName is the name of the application's folder in the user's config directory.
+const AppName = "game-save-backup-manager"
+
+// ConfigEnv is the environment variable that overrides the config file location.
+const ConfigEnv = "GSBM_CONFIG"
+
+// fileName is the name of the configuration file.
+const fileName = "config.json"
+
+// portableMarker is a file next to the executable that turns on portable mode.
+const portableMarker = "portable"
+
+// Sources of the config file location, in order of precedence
+const (
+	SourceFlag     = "--config flag"
+	SourceEnv      = ConfigEnv
+	SourcePortable = "portable mode"
+	SourceDefault  = "default"
+)
+
+// LocateOptions carries the command-line settings that affect where the
+// configuration file is.
+type LocateOptions struct {
+	// Path is an explicit config file, as given with --config.
+	Path string
+	// Portable keeps the config next to the executable, as with --portable.
+	Portable bool
+}
+
+// Location is where the configuration file lives.
+type Location struct {
+	Path   string
+	Source string // one of the Source constants
+
+	// MigratedFrom is the config next to the executable that was moved to
+	// Path, if one was.
+	MigratedFrom string
+}
+
+// Locate finds the configuration file. In order of precedence it is
+//
+//   - the file given with --config
+//   - the file named by $GSBM_CONFIG
+//   - config.json next to the executable in portable mode, which is turned
+//     on with --portable or by a file named "portable" next to the executable
+//   - $XDG_CONFIG_HOME/game-save-backup-manager/config.json
+//
+// When the default location is used but has no config yet, a config.json
+// left next to the executable by an older release is moved there.
+func Locate(opts LocateOptions) (Location, error) {
+	if opts.Path != "" {
+		path, err := paths.Expand(opts.Path, paths.Options{})
+		return Location{Path: path, Source: SourceFlag}, err
+	}
+	if env := os.Getenv(ConfigEnv); env != "" {
+		path, err := paths.Expand(env, paths.Options{})
+		return Location{Path: path, Source: SourceEnv}, err
+	}
+
+	exePath, err := exeConfigPath()
+	if err != nil {
+		return Location{}, err
+	}
+	if opts.Portable || fileExists(filepath.Join(filepath.Dir(exePath), portableMarker)) {
+		return Location{Path: exePath, Source: SourcePortable}, nil
+	}
+
+	path, err := paths.Expand("<xdgConfig>/"+AppName+"/"+fileName, paths.Options{})
+	if err != nil {
+		return Location{}, err
+	}
+	loc := Location{Path: path, Source: SourceDefault}
+	if !fileExists(path) && fileExists(exePath) {
+		if err := migrate(exePath, path); err != nil {
+			return Location{}, fmt.Errorf("failed to move %s to %s: %v", exePath, path, err)
+		}
+		loc.MigratedFrom = exePath
+	}
+	return loc, nil
+}
+
+// migrate copies the config at from to to and renames the original, so it
+// is clear it is no longer read. The original is left in place if its
+// directory isn't writable.
+func migrate(from, to string) error {
+	data, err := os.ReadFile(from)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(to, data, 0644); err != nil {
+		return err
+	}
+	os.Rename(from, from+".migrated")
+	return nil
+}
+
+// executable returns the path of the running program. Tests replace it.
+var executable = os.Executable
+
+// exeConfigPath returns the path of config.json next to the executable.
+func exeConfigPath() (string, error) {
+	exePath, err := executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(exePath), fileName), nil
+}
+
+// fileExists reports whether path exists.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocate(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     LocateOptions
+		env      string
+		portable bool // a "portable" file next to the executable
+		want     Location
+	}{
+		{
+			name: "default",
+			want: Location{Path: "<xdg>/game-save-backup-manager/config.json", Source: SourceDefault},
+		},
+		{
+			name: "flag",
+			opts: LocateOptions{Path: "<home>/flag.json", Portable: true},
+			env:  "<home>/env.json",
+			want: Location{Path: "<home>/flag.json", Source: SourceFlag},
+		},
+		{
+			name:     "environment",
+			opts:     LocateOptions{Portable: true},
+			env:      "<home>/env.json",
+			portable: true,
+			want:     Location{Path: "<home>/env.json", Source: SourceEnv},
+		},
+		{
+			name: "portable flag",
+			opts: LocateOptions{Portable: true},
+			want: Location{Path: "<exe>/config.json", Source: SourcePortable},
+		},
+		{
+			name:     "portable marker",
+			portable: true,
+			want:     Location{Path: "<exe>/config.json", Source: SourcePortable},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home, xdg, exe := locateEnv(t)
+			expand := func(path string) string {
+				for _, dir := range []struct{ name, path string }{{"<home>", home}, {"<xdg>", xdg}, {"<exe>", exe}} {
+					if rest, ok := strings.CutPrefix(path, dir.name); ok {
+						return filepath.Join(dir.path, filepath.FromSlash(rest))
+					}
+				}
+				return path
+			}
+			if tt.env != "" {
+				t.Setenv(ConfigEnv, expand(tt.env))
+			}
+			if tt.portable {
+				writeFile(t, filepath.Join(exe, portableMarker), "")
+			}
+			tt.opts.Path = expand(tt.opts.Path)
+
+			got, err := Locate(tt.opts)
+			if err != nil {
+				t.Fatalf("Locate() error = %v", err)
+			}
+			want := tt.want
+			want.Path = expand(want.Path)
+			if got != want {
+				t.Errorf("Locate() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestLocateMovesConfigFromExecutable(t *testing.T) {
+	_, xdg, exe := locateEnv(t)
+	old := filepath.Join(exe, fileName)
+	writeFile(t, old, `{"version": 1}`)
+
+	loc, err := Locate(LocateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(xdg, AppName, fileName)
+	if loc.Path != want || loc.MigratedFrom != old {
+		t.Errorf("Locate() = %+v, want the config moved from %s to %s", loc, old, want)
+	}
+	if data, err := os.ReadFile(want); err != nil || string(data) != `{"version": 1}` {
+		t.Errorf("moved config = %q, %v", data, err)
+	}
+	if fileExists(old) || !fileExists(old+".migrated") {
+		t.Errorf("old config was not renamed to %s", old+".migrated")
+	}
+
+	// Once moved, an existing config is never replaced
+	writeFile(t, old, `{"version": 0}`)
+	loc, err = Locate(LocateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.MigratedFrom != "" {
+		t.Errorf("MigratedFrom = %q, want the existing config kept", loc.MigratedFrom)
+	}
+}
+
+// locateEnv points the home, config and executable directories at
+// temporary directories and returns them.
+func locateEnv(t *testing.T) (home, xdg, exe string) {
+	t.Helper()
+	home, xdg, exe = t.TempDir(), t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("APPDATA", xdg)
+	t.Setenv(ConfigEnv, "")
+
+	saved := executable
+	executable = func() (string, error) { return filepath.Join(exe, "gsbm"), nil }
+	t.Cleanup(func() { executable = saved })
+	return home, xdg, exe
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
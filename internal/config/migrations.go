@@ -0,0 +1,90 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// migration upgrades a config file by one version. It works on the decoded
+// JSON document, so fields can be moved or renamed before the result is
+// read into Config.
+type migration struct {
+	version     int
+	description string
+	up          func(doc map[string]interface{}) error
+}
+
+// migrations lists every change to the config file format in order.
+// Configs written before the version key existed are version 0.
+var migrations = []migration{
+	{1, "move save_path and auto_backup into a game profile", func(doc map[string]interface{}) error {
+		savePath, _ := doc["save_path"].(string)
+		autoBackup, _ := doc["auto_backup"].(bool)
+		delete(doc, "save_path")
+		delete(doc, "auto_backup")
+
+		if games, _ := doc["games"].([]interface{}); len(games) > 0 || savePath == "" {
+			return nil
+		}
+		doc["games"] = []interface{}{map[string]interface{}{
+			"id":          slugify(DefaultGameName),
+			"name":        DefaultGameName,
+			"save_path":   savePath,
+			"auto_backup": autoBackup,
+		}}
+		return nil
+	}},
+}
+
+// ConfigVersion is the newest config file format this build understands.
+var ConfigVersion = migrations[len(migrations)-1].version
+
+// upgrade brings the config file contents in data up to ConfigVersion. It
+// reports whether anything changed, in which case the original file at path
+// has been copied to a backup first. Files written by a newer release are
+// rejected, since saving them would drop the settings this build doesn't know.
+func upgrade(path string, data []byte) ([]byte, bool, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, false, fmt.Errorf("%s: %v", path, err)
+	}
+
+	version := 0
+	if v, ok := doc["version"]; ok {
+		n, ok := v.(float64)
+		if !ok || n < 0 || n != float64(int(n)) {
+			return nil, false, fmt.Errorf("%s: version must be a whole number, not %v", path, v)
+		}
+		version = int(n)
+	}
+	if version > ConfigVersion {
+		return nil, false, fmt.Errorf("%s uses config version %d, but this version of the application only supports up to version %d; please upgrade the application",
+			path, version, ConfigVersion)
+	}
+	if version == ConfigVersion {
+		return data, false, nil
+	}
+
+	backupPath := fmt.Sprintf("%s.v%d-%s.bak", path, version, time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(backupPath, data, 0644); err != nil {
+		return nil, false, fmt.Errorf("failed to back up config before migrating: %v", err)
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		if err := m.up(doc); err != nil {
+			return nil, false, fmt.Errorf("config migration %d (%s) failed: %v", m.version, m.description, err)
+		}
+	}
+	doc["version"] = ConfigVersion
+
+	upgraded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, false, err
+	}
+	return upgraded, true, nil
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUpgrade(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string // the upgraded document, or "" if it is left as is
+	}{
+		{
+			name: "version 0",
+			in:   `{"backup_dir": "/b", "save_path": "/s", "auto_backup": true}`,
+			want: `{"version": 1, "backup_dir": "/b",
+				"games": [{"id": "default", "name": "Default", "save_path": "/s", "auto_backup": true}]}`,
+		},
+		{
+			name: "version 0 without a save path",
+			in:   `{"backup_dir": "/b", "auto_backup": true}`,
+			want: `{"version": 1, "backup_dir": "/b"}`,
+		},
+		{
+			name: "version 0 with games",
+			in:   `{"version": 0, "save_path": "/s", "games": [{"id": "hades", "save_path": "/h"}]}`,
+			want: `{"version": 1, "games": [{"id": "hades", "save_path": "/h"}]}`,
+		},
+		{
+			name: "current version",
+			in:   `{"version": 1, "save_path": "/s"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			got, upgraded, err := upgrade(path, []byte(tt.in))
+			if err != nil {
+				t.Fatalf("upgrade() error = %v", err)
+			}
+			backups, _ := filepath.Glob(path + ".v0-*.bak")
+
+			if tt.want == "" {
+				if upgraded || string(got) != tt.in || len(backups) != 0 {
+					t.Errorf("upgrade() = %s, %v with backups %v; want the config left as is", got, upgraded, backups)
+				}
+				return
+			}
+			if !upgraded {
+				t.Error("upgrade() did not report the config as upgraded")
+			}
+			if !reflect.DeepEqual(decode(t, string(got)), decode(t, tt.want)) {
+				t.Errorf("upgrade() = %s, want %s", got, tt.want)
+			}
+			if len(backups) != 1 {
+				t.Fatalf("backups = %v, want one copy of the original", backups)
+			}
+			if data, _ := os.ReadFile(backups[0]); string(data) != tt.in {
+				t.Errorf("backup = %s, want %s", data, tt.in)
+			}
+		})
+	}
+}
+
+func TestUpgradeErrors(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`{"version": 2}`, "only supports up to version 1"},
+		{`{"version": "1"}`, "must be a whole number"},
+		{`{"version": 0.5}`, "must be a whole number"},
+		{`{"version": -1}`, "must be a whole number"},
+		{`[1]`, "cannot unmarshal"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if _, _, err := upgrade(path, []byte(tt.in)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("upgrade(%s) error = %v, want one containing %q", tt.in, err, tt.want)
+		}
+		if backups, _ := filepath.Glob(path + ".*.bak"); len(backups) != 0 {
+			t.Errorf("upgrade(%s) backed up the config to %v", tt.in, backups)
+		}
+	}
+}
+
+func TestLoadSavesUpgradedConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{"backup_dir": "/b", "save_path": "/s"}`)
+
+	c, _, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version != ConfigVersion || len(c.Games) != 1 || c.Games[0].SavePath != "/s" {
+		t.Errorf("Load() = %+v, want version %d with the save path in a game", c, ConfigVersion)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc := decode(t, string(data)); doc["version"] != float64(ConfigVersion) || doc["save_path"] != nil {
+		t.Errorf("saved config = %s, want it upgraded", data)
+	}
+}
+
+func decode(t *testing.T, data string) map[string]interface{} {
+	t.Helper()
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &doc); err != nil {
+		t.Fatalf("%s: %v", data, err)
+	}
+	return doc
+}
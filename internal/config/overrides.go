@@ -0,0 +1,347 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix starts the name of every environment variable that overrides a
+// setting, as in GSBM_BACKUP_DIR.
+const EnvPrefix = "GSBM_"
+
+// setting is a configuration value that can be overridden from the
+// environment or the command line.
+type setting struct {
+	// key is the field's name in config.json. Nested fields are joined with
+	// a dot, as in "retention.keep_last".
+	key string
+	// game settings belong to the active game profile.
+	game   bool
+	isBool bool
+	usage  string
+
+	get func(c *Config, g *Game) string
+	set func(c *Config, g *Game, value string) error
+}
+
+// settings lists every overridable setting. active_game comes first so the
+// game settings after it apply to the chosen game.
+var settings = []setting{
+	{key: "active_game", usage: "ID of the game to work on",
+		get: func(c *Config, _ *Game) string { return c.ActiveGame },
+		set: func(c *Config, _ *Game, v string) error {
+			if v == "" {
+				c.ActiveGame = ""
+			} else if !c.SetActiveGame(v) {
+				return fmt.Errorf("no game has the ID %q", v)
+			}
+			return nil
+		}},
+	{key: "backup_dir", usage: "directory holding the backups",
+		get: func(c *Config, _ *Game) string { return c.BackupDir },
+		set: func(c *Config, _ *Game, v string) error { c.BackupDir = v; return nil }},
+	{key: "compression", usage: "codec for new backups: none, gzip or zstd",
+		get: func(c *Config, _ *Game) string { return c.Compression },
+		set: func(c *Config, _ *Game, v string) error { c.Compression = v; return nil }},
+	{key: "compression_level", usage: "codec-specific compression level",
+		get: func(c *Config, _ *Game) string { return formatInt(c.CompressionLevel) },
+		set: func(c *Config, _ *Game, v string) error { return parseInt(v, &c.CompressionLevel) }},
+	{key: "retention.keep_last", usage: "number of most recent backups to keep",
+		get: func(c *Config, _ *Game) string { return formatInt(c.Retention.KeepLast) },
+		set: func(c *Config, _ *Game, v string) error { return parseInt(v, &c.Retention.KeepLast) }},
+	{key: "retention.keep_daily", usage: "number of days to keep a daily backup for",
+		get: func(c *Config, _ *Game) string { return formatInt(c.Retention.KeepDaily) },
+		set: func(c *Config, _ *Game, v string) error { return parseInt(v, &c.Retention.KeepDaily) }},
+	{key: "retention.keep_weekly", usage: "number of weeks to keep a weekly backup for",
+		get: func(c *Config, _ *Game) string { return formatInt(c.Retention.KeepWeekly) },
+		set: func(c *Config, _ *Game, v string) error { return parseInt(v, &c.Retention.KeepWeekly) }},
+	{key: "retention.keep_monthly", usage: "number of months to keep a monthly backup for",
+		get: func(c *Config, _ *Game) string { return formatInt(c.Retention.KeepMonthly) },
+		set: func(c *Config, _ *Game, v string) error { return parseInt(v, &c.Retention.KeepMonthly) }},
+	{key: "trash_days", usage: "days deleted backups stay in the trash",
+		get: func(c *Config, _ *Game) string { return formatInt(c.TrashDays) },
+		set: func(c *Config, _ *Game, v string) error { return parseInt(v, &c.TrashDays) }},
+	{key: "reconcile_on_startup", isBool: true, usage: "check the database against the disk at startup",
+		get: func(c *Config, _ *Game) string { return strconv.FormatBool(c.ReconcileOnStartup) },
+		set: func(c *Config, _ *Game, v string) error { return parseBool(v, &c.ReconcileOnStartup) }},
+	{key: "manifest", usage: "save-location manifest to offer when importing",
+		get: func(c *Config, _ *Game) string { return c.Manifest },
+		set: func(c *Config, _ *Game, v string) error { c.Manifest = v; return nil }},
+
+	{key: "save_path", game: true, usage: "save file or directory of the active game",
+		get: func(_ *Config, g *Game) string { return g.SavePath },
+		set: func(_ *Config, g *Game, v string) error { g.SavePath = v; return nil }},
+	{key: "prefix", game: true, usage: "Wine/Proton prefix of the active game",
+		get: func(_ *Config, g *Game) string { return g.Prefix },
+		set: func(_ *Config, g *Game, v string) error { g.Prefix = v; return nil }},
+	{key: "auto_backup", game: true, isBool: true, usage: "back up the active game's save before restoring",
+		get: func(_ *Config, g *Game) string { return strconv.FormatBool(g.AutoBackup) },
+		set: func(_ *Config, g *Game, v string) error { return parseBool(v, &g.AutoBackup) }},
+	{key: "include", game: true, usage: "comma-separated glob patterns of files to back up",
+		get: func(_ *Config, g *Game) string { return strings.Join(g.Include, ",") },
+		set: func(_ *Config, g *Game, v string) error { g.Include = splitPatterns(v); return nil }},
+	{key: "exclude", game: true, usage: "comma-separated glob patterns of files to leave out",
+		get: func(_ *Config, g *Game) string { return strings.Join(g.Exclude, ",") },
+		set: func(_ *Config, g *Game, v string) error { g.Exclude = splitPatterns(v); return nil }},
+}
+
+// envName returns the environment variable that overrides a setting.
+func (s setting) envName() string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(s.key, ".", "_"))
+}
+
+// flagName returns the command-line flag that overrides a setting.
+func (s setting) flagName() string {
+	return strings.NewReplacer("_", "-", ".", "-").Replace(s.key)
+}
+
+// Override is a setting given outside the config file.
+type Override struct {
+	Key    string // the setting's name in config.json
+	Value  string
+	Source string // the environment variable or flag it came from
+}
+
+// EnvOverrides returns the settings given as GSBM_* environment variables.
+func EnvOverrides() []Override {
+	var overrides []Override
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.envName()); ok {
+			overrides = append(overrides, Override{Key: s.key, Value: value, Source: "$" + s.envName()})
+		}
+	}
+	return overrides
+}
+
+// FlagOverrides collects the settings given on the command line.
+type FlagOverrides struct {
+	overrides []Override
+}
+
+// RegisterFlags adds a flag for every setting to fs, such as --backup-dir
+// and --auto-backup. The values given are available from Overrides once fs
+// has been parsed.
+func RegisterFlags(fs *flag.FlagSet) *FlagOverrides {
+	f := &FlagOverrides{}
+	for _, s := range settings {
+		fs.Var(&overrideFlag{setting: s, into: f}, s.flagName(), s.usage+" (or $"+s.envName()+")")
+	}
+	return f
+}
+
+// Overrides returns the settings given on the command line, in order.
+func (f *FlagOverrides) Overrides() []Override {
+	return f.overrides
+}
+
+// overrideFlag is the flag.Value of one setting.
+type overrideFlag struct {
+	setting setting
+	into    *FlagOverrides
+}
+
+func (o *overrideFlag) String() string { return "" }
+
+func (o *overrideFlag) Set(value string) error {
+	o.into.overrides = append(o.into.overrides, Override{
+		Key:    o.setting.key,
+		Value:  value,
+		Source: "--" + o.setting.flagName(),
+	})
+	return nil
+}
+
+// IsBoolFlag lets boolean settings be given without a value, as in --auto-backup.
+func (o *overrideFlag) IsBoolFlag() bool { return o.setting.isBool }
+
+// applied remembers an override so Save can write the file's own value back.
+type applied struct {
+	setting setting
+	game    string // ID of the game for game settings
+	file    string // value before the override
+	value   string // value after the override
+	source  string
+}
+
+// ApplyOverrides layers settings from the environment or command line over
+// the values from the file, in order, so later overrides win. Overridden
+// values are not written to the file by Save unless they are changed again.
+func (c *Config) ApplyOverrides(overrides []Override) error {
+	// Choose the game first, so game settings apply to it
+	ordered := make([]Override, 0, len(overrides))
+	for _, o := range overrides {
+		if o.Key == "active_game" {
+			ordered = append(ordered, o)
+		}
+	}
+	for _, o := range overrides {
+		if o.Key != "active_game" {
+			ordered = append(ordered, o)
+		}
+	}
+
+	// With no game yet, a save path given this way makes one up for this
+	// run; it is only saved to the file if it is changed there
+	if len(c.Games) == 0 {
+		for _, o := range ordered {
+			if o.Key == "save_path" {
+				c.overrideGame = c.AddGame(DefaultGameName, "").ID
+				break
+			}
+		}
+	}
+
+	for _, o := range ordered {
+		s, ok := findSetting(o.Key)
+		if !ok {
+			return fmt.Errorf("%s: unknown setting %q", o.Source, o.Key)
+		}
+
+		var g *Game
+		if s.game {
+			if g = c.ActiveGameProfile(); g == nil {
+				return fmt.Errorf("%s: no game is configured yet to apply it to", o.Source)
+			}
+		}
+
+		file := s.get(c, g)
+		if prev := c.findApplied(s.key, g); prev != nil {
+			file = prev.file
+		}
+		if err := s.set(c, g, o.Value); err != nil {
+			return fmt.Errorf("%s: %v", o.Source, err)
+		}
+
+		a := applied{setting: s, file: file, value: s.get(c, g), source: o.Source}
+		if g != nil {
+			a.game = g.ID
+		}
+		if prev := c.findApplied(s.key, g); prev != nil {
+			*prev = a
+		} else {
+			c.overrides = append(c.overrides, a)
+		}
+	}
+	return nil
+}
+
+// findApplied returns the override applied to a setting, or nil.
+func (c *Config) findApplied(key string, g *Game) *applied {
+	for i := range c.overrides {
+		a := &c.overrides[i]
+		if a.setting.key == key && (g == nil || a.game == g.ID) {
+			return a
+		}
+	}
+	return nil
+}
+
+// forFile returns a copy of the configuration with every override that is
+// still in effect replaced by the file's own value.
+func (c *Config) forFile() *Config {
+	out := *c
+	out.Games = append([]Game(nil), c.Games...)
+	for _, a := range c.overrides {
+		var g *Game
+		if a.setting.game {
+			if g = out.FindGame(a.game); g == nil {
+				continue
+			}
+		}
+		if a.setting.get(&out, g) == a.value {
+			a.setting.set(&out, g, a.file)
+		}
+	}
+
+	// Leave out the game made up for a save path override while it has none
+	// of its own
+	if c.overrideGame != "" {
+		if g := out.FindGame(c.overrideGame); g != nil && g.SavePath == "" {
+			out.Games = slices.DeleteFunc(out.Games, func(g Game) bool { return g.ID == c.overrideGame })
+			if out.ActiveGame == c.overrideGame {
+				out.ActiveGame = ""
+			}
+		}
+	}
+	return &out
+}
+
+// Value is one setting of the effective configuration.
+type Value struct {
+	Key    string // e.g. "backup_dir" or "games[elden-ring].save_path"
+	Value  string
+	Source string // the overriding variable or flag, "config file" or "default"
+}
+
+// Effective lists every setting after overrides, with where its value came
+// from. Game settings are listed for each game.
+func (c *Config) Effective() []Value {
+	var values []Value
+	source := func(s setting, g *Game) string {
+		if a := c.findApplied(s.key, g); a != nil && s.get(c, g) == a.value {
+			return a.source
+		}
+		if s.key == "active_game" && c.overrideGame != "" && c.ActiveGame == c.overrideGame {
+			return "default"
+		}
+		if v := s.get(c, g); v == "" || v == "0" || v == "false" {
+			return "default"
+		}
+		return "config file"
+	}
+
+	for _, s := range settings {
+		if !s.game {
+			values = append(values, Value{Key: s.key, Value: s.get(c, nil), Source: source(s, nil)})
+		}
+	}
+	for i := range c.Games {
+		g := &c.Games[i]
+		for _, s := range settings {
+			if s.game {
+				values = append(values, Value{
+					Key:    fmt.Sprintf("games[%s].%s", g.ID, s.key),
+					Value:  s.get(c, g),
+					Source: source(s, g),
+				})
+			}
+		}
+	}
+	return values
+}
+
+// findSetting returns the setting with the given key.
+func findSetting(key string) (setting, bool) {
+	for _, s := range settings {
+		if s.key == key {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+func formatInt(n int) string {
+	return strconv.Itoa(n)
+}
+
+func parseInt(value string, into *int) error {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("%q is not a whole number", value)
+	}
+	*into = n
+	return nil
+}
+
+func parseBool(value string, into *bool) error {
+	b, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("%q is not true or false", value)
+	}
+	*into = b
+	return nil
+}
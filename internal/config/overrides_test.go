@@ -0,0 +1,172 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApplyOverrides(t *testing.T) {
+	newConfig := func() *Config {
+		c := &Config{BackupDir: "/backups", Retention: Retention{KeepLast: 5}}
+		c.AddGame("Hades", "/saves/hades")
+		c.AddGame("Celeste", "/saves/celeste")
+		return c
+	}
+	tests := []struct {
+		name      string
+		overrides []Override
+		check     func(c *Config) bool
+	}{
+		{"top-level setting", []Override{{Key: "backup_dir", Value: "/elsewhere"}},
+			func(c *Config) bool { return c.BackupDir == "/elsewhere" }},
+		{"later overrides win", []Override{{Key: "retention.keep_last", Value: "1"}, {Key: "retention.keep_last", Value: "2"}},
+			func(c *Config) bool { return c.Retention.KeepLast == 2 }},
+		{"game setting applies to the active game", []Override{{Key: "save_path", Value: "/s"}},
+			func(c *Config) bool { return c.Games[0].SavePath == "/s" && c.Games[1].SavePath == "/saves/celeste" }},
+		{"active game is chosen first", []Override{{Key: "auto_backup", Value: "true"}, {Key: "active_game", Value: "celeste"}},
+			func(c *Config) bool {
+				return c.ActiveGame == "celeste" && c.Games[1].AutoBackup && !c.Games[0].AutoBackup
+			}},
+		{"patterns", []Override{{Key: "include", Value: "*.sav, *.dat"}},
+			func(c *Config) bool { return reflect.DeepEqual(c.Games[0].Include, []string{"*.sav", "*.dat"}) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newConfig()
+			if err := c.ApplyOverrides(tt.overrides); err != nil {
+				t.Fatalf("ApplyOverrides() error = %v", err)
+			}
+			if !tt.check(c) {
+				t.Errorf("ApplyOverrides(%v) = %+v", tt.overrides, c)
+			}
+		})
+	}
+}
+
+func TestApplyOverridesErrors(t *testing.T) {
+	tests := []struct {
+		override Override
+		want     string
+	}{
+		{Override{Key: "nope", Source: "$GSBM_NOPE"}, `$GSBM_NOPE: unknown setting "nope"`},
+		{Override{Key: "active_game", Value: "portal", Source: "--active-game"}, `no game has the ID "portal"`},
+		{Override{Key: "trash_days", Value: "soon", Source: "--trash-days"}, "is not a whole number"},
+		{Override{Key: "auto_backup", Value: "maybe", Source: "--auto-backup"}, "is not true or false"},
+	}
+	for _, tt := range tests {
+		c := &Config{}
+		c.AddGame("Hades", "/saves")
+		if err := c.ApplyOverrides([]Override{tt.override}); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ApplyOverrides(%v) error = %v, want one containing %q", tt.override, err, tt.want)
+		}
+	}
+}
+
+func TestOverridesAreNotSaved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	c := &Config{path: path, BackupDir: "/backups"}
+	c.AddGame("Hades", "/saves")
+	err := c.ApplyOverrides([]Override{
+		{Key: "backup_dir", Value: "/elsewhere"},
+		{Key: "save_path", Value: "/other"},
+		{Key: "trash_days", Value: "7"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Changed again after the override, so it is saved
+	c.TrashDays = 14
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := readConfig(t, path)
+	if saved.BackupDir != "/backups" || saved.Games[0].SavePath != "/saves" || saved.TrashDays != 14 {
+		t.Errorf("saved %+v, want the file's values with trash_days 14", saved)
+	}
+	if c.BackupDir != "/elsewhere" || c.Games[0].SavePath != "/other" {
+		t.Errorf("Save() reverted the overrides in memory: %+v", c)
+	}
+}
+
+func TestOverridesWithoutGames(t *testing.T) {
+	t.Run("a save path makes up a game", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		c := &Config{path: path}
+		if c.Complete() {
+			t.Fatal("Complete() = true for an empty config")
+		}
+		err := c.ApplyOverrides([]Override{
+			{Key: "include", Value: "*.sav"},
+			{Key: "save_path", Value: "/saves", Source: "$GSBM_SAVE_PATH"},
+			{Key: "backup_dir", Value: "/backups"},
+		})
+		if err != nil {
+			t.Fatalf("ApplyOverrides() error = %v", err)
+		}
+		g := c.ActiveGameProfile()
+		if g == nil || g.Name != DefaultGameName || g.SavePath != "/saves" || len(g.Include) != 1 {
+			t.Fatalf("active game = %+v, want a default game with the overrides", g)
+		}
+		if !c.Complete() {
+			t.Error("Complete() = false with the backup directory and save path overridden")
+		}
+		want := Value{Key: "games[default].save_path", Value: "/saves", Source: "$GSBM_SAVE_PATH"}
+		if values := c.Effective(); values[0].Source != "default" || !containsValue(values, want) {
+			t.Errorf("Effective() = %v, want active_game from the default and %v", values, want)
+		}
+
+		if err := c.Save(); err != nil {
+			t.Fatal(err)
+		}
+		if saved := readConfig(t, path); len(saved.Games) != 0 || saved.ActiveGame != "" {
+			t.Errorf("saved %+v, want the made-up game left out", saved)
+		}
+
+		// Once given a save path of its own, the game is saved
+		g.SavePath = "/mine"
+		if err := c.Save(); err != nil {
+			t.Fatal(err)
+		}
+		if saved := readConfig(t, path); len(saved.Games) != 1 || saved.Games[0].SavePath != "/mine" {
+			t.Errorf("saved %+v, want the game with its own save path", saved)
+		}
+	})
+
+	t.Run("other game settings need a game", func(t *testing.T) {
+		c := &Config{}
+		err := c.ApplyOverrides([]Override{{Key: "prefix", Value: "~/pfx", Source: "--prefix"}})
+		if err == nil || !strings.Contains(err.Error(), "no game is configured") {
+			t.Errorf("ApplyOverrides() error = %v, want one saying no game is configured", err)
+		}
+		if len(c.Games) != 0 {
+			t.Errorf("games = %+v, want none made up", c.Games)
+		}
+	})
+}
+
+func containsValue(values []Value, want Value) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
+
+func readConfig(t *testing.T, path string) Config {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
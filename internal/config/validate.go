@@ -0,0 +1,376 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/backup"
+)
+
+// FieldError is a problem with one field of the configuration.
+type FieldError struct {
+	// Game is the index in Games of the game the field belongs to, or -1
+	// for a top-level field.
+	Game int
+	// Field is the JSON name of the field, such as "save_path".
+	Field   string
+	Message string
+}
+
+// Path returns where the field is in config.json, such as "games[0].save_path".
+func (e FieldError) Path() string {
+	if e.Game < 0 {
+		return e.Field
+	}
+	return fmt.Sprintf("games[%d].%s", e.Game, e.Field)
+}
+
+func (e FieldError) Error() string {
+	return e.Path() + ": " + e.Message
+}
+
+// ValidationErrors lists every problem found in a configuration.
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, len(v))
+	for i, e := range v {
+		msgs[i] = e.Error()
+	}
+	return "invalid configuration:\n  " + strings.Join(msgs, "\n  ")
+}
+
+// OnlyGames reports whether every problem is with a game profile rather
+// than a setting the whole application depends on.
+func (v ValidationErrors) OnlyGames() bool {
+	for _, e := range v {
+		if e.Game < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks the configuration against the disk: required fields are
+// set, save paths exist and can be read, the backup directory can be
+// written, no save directory contains the backup directory, and the
+// compression and retention settings are usable.
+func (c *Config) Validate() ValidationErrors {
+	var errs ValidationErrors
+	add := func(game int, field, format string, args ...interface{}) {
+		errs = append(errs, FieldError{Game: game, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	backupDir := ""
+	if c.BackupDir == "" {
+		add(-1, "backup_dir", "is required")
+	} else if dir, err := c.ResolveBackupDir(); err != nil {
+		add(-1, "backup_dir", "%v", err)
+	} else if err := checkWritableDir(dir); err != nil {
+		add(-1, "backup_dir", "%v", err)
+	} else {
+		backupDir = dir
+	}
+
+	if len(c.Games) == 0 {
+		add(-1, "games", "at least one game is required")
+	}
+	checkSettings(-1, c.Compression, c.CompressionLevel, &c.Retention, add)
+
+	ids := make(map[string]int)
+	for i := range c.Games {
+		g := &c.Games[i]
+		if g.Name == "" {
+			add(i, "name", "is required")
+		}
+		if g.ID == "" {
+			add(i, "id", "is required")
+		} else if first, ok := ids[g.ID]; ok {
+			add(i, "id", "%q is also used by games[%d]", g.ID, first)
+		} else {
+			ids[g.ID] = i
+		}
+
+		if g.SavePath == "" {
+			add(i, "save_path", "is required")
+		} else if save, err := g.ResolveSavePath(); err != nil {
+			add(i, "save_path", "%v", err)
+		} else if err := checkReadable(save); err != nil {
+			add(i, "save_path", "%v", err)
+		} else if backupDir != "" && within(backupDir, save) {
+			add(i, "save_path", "contains the backup directory %s; backups would end up inside the save", backupDir)
+		}
+
+		for _, field := range []struct {
+			name     string
+			patterns []string
+		}{{"include", g.Include}, {"exclude", g.Exclude}} {
+			for _, p := range field.patterns {
+				if !doublestar.ValidatePattern(p) {
+					add(i, field.name, "%q is not a valid glob pattern", p)
+				}
+			}
+		}
+
+		// A game's level is only used along with its own codec
+		codec, level := g.Compression, g.CompressionLevel
+		if codec == "" {
+			level = 0
+		}
+		checkSettings(i, codec, level, g.Retention, add)
+	}
+
+	return errs
+}
+
+// retentionFields lists the retention counts by their path in config.json.
+var retentionFields = []struct {
+	name  string
+	field func(r *Retention) *int
+}{
+	{"retention.keep_last", func(r *Retention) *int { return &r.KeepLast }},
+	{"retention.keep_daily", func(r *Retention) *int { return &r.KeepDaily }},
+	{"retention.keep_weekly", func(r *Retention) *int { return &r.KeepWeekly }},
+	{"retention.keep_monthly", func(r *Retention) *int { return &r.KeepMonthly }},
+}
+
+// checkSettings checks the compression and retention settings that both
+// the configuration and each game have. A nil retention is not set.
+func checkSettings(game int, codec string, level int, r *Retention, add func(int, string, string, ...interface{})) {
+	if err := backup.ValidateCompression(backup.Compression{Codec: codec}); err != nil {
+		add(game, "compression", "%q is not a supported codec; use %s, %s or %s",
+			codec, backup.CodecNone, backup.CodecGzip, backup.CodecZstd)
+	} else if err := backup.ValidateCompression(backup.Compression{Codec: codec, Level: level}); err != nil {
+		add(game, "compression_level", "%v", err)
+	}
+
+	if r == nil {
+		return
+	}
+	for _, f := range retentionFields {
+		if n := *f.field(r); n < 0 {
+			add(game, f.name, "is %d; it must not be negative", n)
+		}
+	}
+}
+
+// checkReadable makes sure a save file or directory exists and can be read.
+func checkReadable(path string) error {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%s does not exist", path)
+	}
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("%s cannot be read: %v", path, err)
+	}
+	defer f.Close()
+	if info.IsDir() {
+		if _, err := f.Readdirnames(1); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("%s cannot be read: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// checkWritableDir makes sure dir is a directory files can be created in.
+// A directory that doesn't exist yet is fine as long as it can be created.
+func checkWritableDir(dir string) error {
+	existing := dir
+	for {
+		info, err := os.Stat(existing)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", existing)
+			}
+			break
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return fmt.Errorf("%s cannot be created", dir)
+		}
+		existing = parent
+	}
+
+	f, err := os.CreateTemp(existing, ".write-test-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable", existing)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return nil
+}
+
+// within reports whether path is dir or inside it.
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// FieldValue returns the current value of the field a problem is about, in
+// the form SetField accepts. Pattern lists are joined with commas.
+func (c *Config) FieldValue(e FieldError) string {
+	if e.Game >= len(c.Games) {
+		return ""
+	}
+	if codec, number := c.setting(e); codec != nil {
+		return *codec
+	} else if number != nil {
+		return strconv.Itoa(*number)
+	}
+	if e.Game < 0 {
+		if e.Field == "backup_dir" {
+			return c.BackupDir
+		}
+		return ""
+	}
+	g := &c.Games[e.Game]
+	switch e.Field {
+	case "name":
+		return g.Name
+	case "id":
+		return g.ID
+	case "save_path":
+		return g.SavePath
+	case "include":
+		return strings.Join(g.Include, ", ")
+	case "exclude":
+		return strings.Join(g.Exclude, ", ")
+	}
+	return ""
+}
+
+// Editable returns why the field a problem is about cannot be set with
+// SetField, or nil if it can.
+func (c *Config) Editable(e FieldError) error {
+	if e.Game >= len(c.Games) {
+		return fmt.Errorf("%s no longer exists", e.Path())
+	}
+	if codec, number := c.setting(e); codec != nil || number != nil {
+		return nil
+	}
+	if e.Game < 0 {
+		if e.Field == "backup_dir" || e.Field == "games" {
+			return nil
+		}
+		return fmt.Errorf("%s cannot be edited here", e.Path())
+	}
+
+	switch e.Field {
+	case "name", "save_path", "include", "exclude":
+		return nil
+	case "id":
+		// Backups are recorded under the game's ID, so changing one that is
+		// set would leave them behind; only a missing ID can be filled in
+		if c.Games[e.Game].ID != "" {
+			return fmt.Errorf("%s cannot be changed here, as backups are recorded under it; "+
+				"remove the duplicate game from the config file, or press 'c' to continue with it", e.Path())
+		}
+		return nil
+	}
+	return fmt.Errorf("%s cannot be edited here", e.Path())
+}
+
+// SetField sets the field a problem is about. For the "games" field, value
+// is the save path of a new game.
+func (c *Config) SetField(e FieldError, value string) error {
+	if err := c.Editable(e); err != nil {
+		return err
+	}
+	value = strings.TrimSpace(value)
+	if codec, number := c.setting(e); codec != nil {
+		*codec = value
+		return nil
+	} else if number != nil {
+		if value == "" {
+			*number = 0
+			return nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s must be a whole number", e.Path())
+		}
+		*number = n
+		return nil
+	}
+	if e.Game < 0 {
+		switch e.Field {
+		case "backup_dir":
+			c.BackupDir = value
+		case "games":
+			c.AddGame(DefaultGameName, value)
+		}
+		return nil
+	}
+
+	g := &c.Games[e.Game]
+	switch e.Field {
+	case "name":
+		g.Name = value
+	case "id":
+		if value == "" {
+			return fmt.Errorf("%s cannot be empty", e.Path())
+		}
+		if other := c.FindGame(value); other != nil {
+			return fmt.Errorf("%s: %q is already the ID of %s", e.Path(), value, other.Name)
+		}
+		if c.ActiveGame == g.ID && c.FindGame(g.ID) == g {
+			c.ActiveGame = value
+		}
+		g.ID = value
+	case "save_path":
+		g.SavePath = value
+	case "include":
+		g.Include = splitPatterns(value)
+	case "exclude":
+		g.Exclude = splitPatterns(value)
+	}
+	return nil
+}
+
+// setting returns the compression codec, or the compression level or
+// retention count, a problem is about. Both are nil for any other field.
+func (c *Config) setting(e FieldError) (codec *string, number *int) {
+	codec, level, r := &c.Compression, &c.CompressionLevel, &c.Retention
+	if e.Game >= 0 {
+		g := &c.Games[e.Game]
+		codec, level, r = &g.Compression, &g.CompressionLevel, g.Retention
+	}
+	switch e.Field {
+	case "compression":
+		return codec, nil
+	case "compression_level":
+		return nil, level
+	}
+	for _, f := range retentionFields {
+		if f.name == e.Field && r != nil {
+			return nil, f.field(r)
+		}
+	}
+	return nil, nil
+}
+
+// splitPatterns splits a comma-separated list of glob patterns.
+func splitPatterns(value string) []string {
+	var patterns []string
+	for _, p := range strings.Split(value, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSetFieldID(t *testing.T) {
+	newConfig := func() *Config {
+		return &Config{
+			Games: []Game{
+				{ID: "elden-ring", Name: "Elden Ring"},
+				{ID: "", Name: "Hades"},
+				{ID: "elden-ring", Name: "Elden Ring Copy"},
+			},
+		}
+	}
+
+	t.Run("a duplicate ID cannot be changed", func(t *testing.T) {
+		c := newConfig()
+		problem := FieldError{Game: 2, Field: "id"}
+		if err := c.Editable(problem); err == nil || !strings.Contains(err.Error(), "backups are recorded under it") {
+			t.Errorf("Editable() error = %v, want one saying backups are recorded under the ID", err)
+		}
+		if err := c.SetField(problem, "elden-ring-copy"); err == nil {
+			t.Error("SetField() changed an ID backups may be recorded under")
+		}
+		if c.Games[2].ID != "elden-ring" {
+			t.Errorf("ID = %q, want it unchanged", c.Games[2].ID)
+		}
+	})
+
+	t.Run("a missing ID can be filled in", func(t *testing.T) {
+		c := newConfig()
+		problem := FieldError{Game: 1, Field: "id"}
+		if err := c.Editable(problem); err != nil {
+			t.Fatalf("Editable() error = %v", err)
+		}
+		if err := c.SetField(problem, "  hades "); err != nil {
+			t.Fatalf("SetField() error = %v", err)
+		}
+		if c.Games[1].ID != "hades" {
+			t.Errorf("ID = %q, want %q", c.Games[1].ID, "hades")
+		}
+	})
+
+	t.Run("the new ID must be set and unused", func(t *testing.T) {
+		for value, want := range map[string]string{"": "cannot be empty", "elden-ring": "already the ID of Elden Ring"} {
+			c := newConfig()
+			err := c.SetField(FieldError{Game: 1, Field: "id"}, value)
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("SetField(%q) error = %v, want one containing %q", value, err, want)
+			}
+			if c.Games[1].ID != "" {
+				t.Errorf("SetField(%q) set the ID to %q", value, c.Games[1].ID)
+			}
+		}
+	})
+}
+
+func TestEditable(t *testing.T) {
+	c := &Config{Games: []Game{{ID: "hades", Name: "Hades"}}}
+	tests := []struct {
+		problem  FieldError
+		editable bool
+	}{
+		{FieldError{Game: -1, Field: "backup_dir"}, true},
+		{FieldError{Game: -1, Field: "games"}, true},
+		{FieldError{Game: -1, Field: "retention"}, false},
+		{FieldError{Game: -1, Field: "compression"}, true},
+		{FieldError{Game: -1, Field: "compression_level"}, true},
+		{FieldError{Game: -1, Field: "retention.keep_daily"}, true},
+		{FieldError{Game: 0, Field: "compression"}, true},
+		{FieldError{Game: 0, Field: "retention.keep_last"}, false}, // the game has no retention of its own
+		{FieldError{Game: 0, Field: "name"}, true},
+		{FieldError{Game: 0, Field: "save_path"}, true},
+		{FieldError{Game: 0, Field: "include"}, true},
+		{FieldError{Game: 0, Field: "exclude"}, true},
+		{FieldError{Game: 0, Field: "id"}, false},
+		{FieldError{Game: 0, Field: "prefix"}, false},
+		{FieldError{Game: 1, Field: "name"}, false},
+	}
+	for _, tt := range tests {
+		if err := c.Editable(tt.problem); (err == nil) != tt.editable {
+			t.Errorf("Editable(%s) error = %v, want editable = %v", tt.problem.Path(), err, tt.editable)
+		}
+	}
+}
+
+func TestValidateSettings(t *testing.T) {
+	backups, saves := t.TempDir(), t.TempDir()
+	tests := []struct {
+		name  string
+		edit  func(c *Config)
+		paths []string // of the fields with problems
+	}{
+		{"defaults", func(c *Config) {}, nil},
+		{"supported settings", func(c *Config) {
+			c.Compression, c.CompressionLevel = "zstd", 19
+			c.Retention = Retention{KeepLast: 5, KeepDaily: 7}
+			c.Games[0].Compression, c.Games[0].CompressionLevel = "gzip", 9
+			c.Games[0].Retention = &Retention{KeepWeekly: 4}
+		}, nil},
+		{"unknown codec", func(c *Config) { c.Compression, c.CompressionLevel = "lzma", 99 }, []string{"compression"}},
+		{"level out of range", func(c *Config) { c.Compression, c.CompressionLevel = "gzip", 10 }, []string{"compression_level"}},
+		{"negative level", func(c *Config) { c.Compression, c.CompressionLevel = "zstd", -1 }, []string{"compression_level"}},
+		{"negative counts", func(c *Config) {
+			c.Retention = Retention{KeepLast: -1, KeepMonthly: -3}
+		}, []string{"retention.keep_last", "retention.keep_monthly"}},
+		{"game settings", func(c *Config) {
+			c.Games[0].Compression, c.Games[0].CompressionLevel = "gzip", 12
+			c.Games[0].Retention = &Retention{KeepDaily: -2}
+		}, []string{"games[0].compression_level", "games[0].retention.keep_daily"}},
+		{"game level without a codec is unused", func(c *Config) { c.Games[0].CompressionLevel = 50 }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{BackupDir: backups}
+			c.AddGame("Hades", saves)
+			tt.edit(c)
+
+			var got []string
+			for _, e := range c.Validate() {
+				got = append(got, e.Path())
+			}
+			if !reflect.DeepEqual(got, tt.paths) {
+				t.Errorf("Validate() found problems with %v, want %v", got, tt.paths)
+			}
+		})
+	}
+}
+
+func TestSetFieldSettings(t *testing.T) {
+	c := &Config{Compression: "lzma", Retention: Retention{KeepLast: -1}}
+	c.AddGame("Hades", "/saves")
+	c.Games[0].Compression, c.Games[0].CompressionLevel = "gzip", 12
+
+	for _, set := range []struct {
+		problem FieldError
+		value   string
+	}{
+		{FieldError{Game: -1, Field: "compression"}, " zstd "},
+		{FieldError{Game: -1, Field: "retention.keep_last"}, "3"},
+		{FieldError{Game: 0, Field: "compression_level"}, ""},
+	} {
+		if got := c.FieldValue(set.problem); got == set.value {
+			t.Errorf("FieldValue(%s) = %q before it was set", set.problem.Path(), got)
+		}
+		if err := c.SetField(set.problem, set.value); err != nil {
+			t.Fatalf("SetField(%s) error = %v", set.problem.Path(), err)
+		}
+	}
+	if c.Compression != "zstd" || c.Retention.KeepLast != 3 || c.Games[0].CompressionLevel != 0 {
+		t.Errorf("settings = %q, %d, %d; want zstd, 3, 0", c.Compression, c.Retention.KeepLast, c.Games[0].CompressionLevel)
+	}
+	if got := c.FieldValue(FieldError{Game: -1, Field: "retention.keep_last"}); got != "3" {
+		t.Errorf("FieldValue(retention.keep_last) = %q, want %q", got, "3")
+	}
+
+	err := c.SetField(FieldError{Game: -1, Field: "compression_level"}, "high")
+	if err == nil || !strings.Contains(err.Error(), "whole number") {
+		t.Errorf("SetField(compression_level, high) error = %v, want one asking for a number", err)
+	}
+}
@@ -0,0 +1,90 @@
+// Package discovery finds installed games and where they keep their saves,
+// so that game profiles can be set up without typing long paths by hand.
+//
+// It looks at Steam libraries (including Proton prefixes under compatdata)
+// and at the Wine prefixes managed by Lutris and Heroic, in both their
+// native and Flatpak locations.
+package discovery
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Sources of discovered games
+const (
+	SourceSteam  = "Steam"
+	SourceProton = "Steam (Proton)"
+	SourceLutris = "Lutris"
+	SourceHeroic = "Heroic"
+)
+
+// Stores a game can be bought from, named as in Ludusavi manifests
+const (
+	StoreSteam = "steam"
+	StoreEpic  = "epic"
+	StoreGOG   = "gog"
+)
+
+// Candidate is an installed game that could become a game profile.
+type Candidate struct {
+	Name       string
+	Source     string
+	AppID      string // Steam app ID, Lutris slug or Heroic app name
+	Store      string // one of the Store constants, or "" if unknown
+	InstallDir string
+	Prefix     string // Wine or Proton prefix; empty for native games
+
+	// SavePath is the suggested save location. It may use <prefix>, which
+	// refers to Prefix. The user is expected to review it.
+	SavePath string
+}
+
+// Discover returns the installed games found on this machine, sorted by
+// name. Launchers that are not installed, or whose files cannot be read,
+// are skipped.
+func Discover() []Candidate {
+	var found []Candidate
+	found = append(found, steamCandidates()...)
+	found = append(found, lutrisCandidates()...)
+	found = append(found, heroicCandidates()...)
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return strings.ToLower(found[i].Name) < strings.ToLower(found[j].Name)
+	})
+	return found
+}
+
+// prefixSavePath suggests a save location inside a Wine prefix: the prefix
+// user's profile directory, where most Windows games keep their saves.
+func prefixSavePath(prefix string) string {
+	users := filepath.Join(prefix, "drive_c", "users")
+	for _, user := range []string{"steamuser", os.Getenv("USER")} {
+		if user == "" {
+			continue
+		}
+		if isDir(filepath.Join(users, user)) {
+			return "<prefix>/drive_c/users/" + user
+		}
+	}
+	return "<prefix>/drive_c"
+}
+
+// isDir reports whether path exists and is a directory.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// homeDir returns the user's home directory, or "" if it is unknown.
+func homeDir() string {
+	home, _ := os.UserHomeDir()
+	return home
+}
+
+// flatpakRoot returns the per-app data root Flatpak uses for an application.
+func flatpakRoot(appID string) string {
+	return filepath.Join(homeDir(), ".var", "app", appID)
+}
@@ -0,0 +1,169 @@
+package discovery
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+// write creates a file below root, with its directories.
+func write(t *testing.T, root, path, data string) {
+	t.Helper()
+	path = filepath.Join(root, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// mkdir creates a directory below root.
+func mkdir(t *testing.T, root, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(root, filepath.FromSlash(path)), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testSteam sets up a Steam installation in home with a second library in
+// lib: a native game with a Steam Cloud folder, a Proton game and a tool.
+func testSteam(t *testing.T, home, lib string) {
+	steam := filepath.Join(home, ".local", "share", "Steam")
+	write(t, steam, "steamapps/libraryfolders.vdf", `"libraryfolders" { "0" { "path" "`+steam+`" } "1" { "path" "`+lib+`" } }`)
+	write(t, steam, "steamapps/appmanifest_100.acf", `"AppState" { "appid" "100" "name" "Native Game" "installdir" "native" }`)
+	write(t, steam, "steamapps/appmanifest_228980.acf", `"AppState" { "appid" "228980" "name" "Steamworks Common Redistributables" }`)
+	mkdir(t, steam, "userdata/12345/100/remote")
+
+	write(t, lib, "steamapps/appmanifest_200.acf", `"AppState" { "appid" "200" "name" "Proton Game" "installdir" "proton" }`)
+	mkdir(t, lib, "steamapps/compatdata/200/pfx/drive_c/users/steamuser")
+}
+
+// testLutris sets up a Lutris installation in home with an installed Wine
+// game and one that was uninstalled.
+func testLutris(t *testing.T, home string) {
+	dir := filepath.Join(home, ".local", "share", "lutris")
+	mkdir(t, dir, "")
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "pga.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	_, err = db.Exec(`
+		CREATE TABLE games (name TEXT, slug TEXT, directory TEXT, configpath TEXT, runner TEXT, installed INTEGER);
+		INSERT INTO games VALUES ('Lutris Game', 'lutris-game', '` + filepath.Join(home, "Games", "lutris-game") + `', 'lutris-game-1', 'wine', 1);
+		INSERT INTO games VALUES ('Removed Game', 'removed', NULL, NULL, 'linux', 0);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, home, ".config/lutris/games/lutris-game-1.yml", "game:\n  prefix: ~/Games/lutris-prefix\n")
+}
+
+// testHeroic sets up a Heroic installation in home with an Epic game in a
+// prefix and a native GOG game.
+func testHeroic(t *testing.T, home string) {
+	heroic := filepath.Join(home, ".config", "heroic")
+	write(t, heroic, "legendaryConfig/legendary/installed.json", `{"Quail": {"title": "Epic Game", "install_path": "/games/epic"}}`)
+	write(t, heroic, "GamesConfig/Quail.json", `{"Quail": {"winePrefix": "~/Games/Heroic/Prefixes/epic"}}`)
+	write(t, heroic, "gog_store/installed.json", `{"installed": [{"appName": "1207658924", "install_path": "/games/gog/GOG Game"}]}`)
+}
+
+func TestDiscover(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the launcher locations below the home directory are only checked on Linux")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USER", "")
+	lib := t.TempDir()
+	testSteam(t, home, lib)
+	testLutris(t, home)
+	testHeroic(t, home)
+
+	want := []Candidate{
+		{Name: "Epic Game", Source: SourceHeroic, AppID: "Quail", Store: StoreEpic, InstallDir: "/games/epic",
+			Prefix: "~/Games/Heroic/Prefixes/epic", SavePath: "<prefix>/drive_c"},
+		{Name: "GOG Game", Source: SourceHeroic, AppID: "1207658924", Store: StoreGOG, InstallDir: "/games/gog/GOG Game",
+			SavePath: "/games/gog/GOG Game"},
+		{Name: "Lutris Game", Source: SourceLutris, AppID: "lutris-game", InstallDir: filepath.Join(home, "Games", "lutris-game"),
+			Prefix: "~/Games/lutris-prefix", SavePath: "<prefix>/drive_c"},
+		{Name: "Native Game", Source: SourceSteam, AppID: "100", Store: StoreSteam,
+			InstallDir: filepath.Join(home, ".local", "share", "Steam", "steamapps", "common", "native"),
+			SavePath:   "~/.local/share/Steam/userdata/12345/100/remote"},
+		{Name: "Proton Game", Source: SourceProton, AppID: "200", Store: StoreSteam,
+			InstallDir: filepath.Join(lib, "steamapps", "common", "proton"),
+			Prefix:     filepath.Join(lib, "steamapps", "compatdata", "200", "pfx"),
+			SavePath:   "<prefix>/drive_c/users/steamuser"},
+	}
+	got := Discover()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Discover() =")
+		for _, c := range got {
+			t.Errorf("  %+v", c)
+		}
+		t.Errorf("want")
+		for _, c := range want {
+			t.Errorf("  %+v", c)
+		}
+	}
+}
+
+func TestDiscoverWithoutLaunchers(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if got := Discover(); len(got) != 0 {
+		t.Errorf("Discover() = %+v, want nothing", got)
+	}
+}
+
+func TestSteamLibraries(t *testing.T) {
+	root := t.TempDir()
+	tests := []struct {
+		name string
+		vdf  string
+		want []string
+	}{
+		{"no file", "", []string{root}},
+		{"current format", `"libraryfolders" { "0" { "path" "` + root + `" } "1" { "path" "/mnt/games" } }`, []string{root, "/mnt/games"}},
+		{"old format", `"LibraryFolders" { "TimeNextStatsReport" "1" "1" "/mnt/games" }`, []string{root, "/mnt/games"}},
+		{"unreadable", `"libraryfolders" {`, []string{root}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.RemoveAll(filepath.Join(root, "steamapps"))
+			if tt.vdf != "" {
+				write(t, root, "steamapps/libraryfolders.vdf", tt.vdf)
+			}
+			if got := SteamLibraries(root); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SteamLibraries() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSteamTool(t *testing.T) {
+	tests := []struct {
+		name string
+		tool bool
+	}{
+		{"Proton 9.0", true},
+		{"Proton 5.13", true},
+		{"Proton Experimental", true},
+		{"Proton Hotfix", true},
+		{"Proton EasyAntiCheat Runtime", true},
+		{"Proton BattlEye Runtime", true},
+		{"Steam Linux Runtime 3.0 (sniper)", true},
+		{"Steamworks Common Redistributables", true},
+		{"Proton Pulse", false},
+		{"Protonwar", false},
+		{"Elden Ring", false},
+	}
+	for _, tt := range tests {
+		if got := isSteamTool(SteamApp{Name: tt.name}); got != tt.tool {
+			t.Errorf("isSteamTool(%q) = %v, want %v", tt.name, got, tt.tool)
+		}
+	}
+}
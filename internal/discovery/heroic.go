@@ -0,0 +1,136 @@
+package discovery
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/paths"
+)
+
+// heroicRoots lists the native and Flatpak Heroic config directories.
+func heroicRoots() []string {
+	return []string{
+		filepath.Join(homeDir(), ".config", "heroic"),
+		filepath.Join(flatpakRoot("com.heroicgameslauncher.hgl"), "config", "heroic"),
+	}
+}
+
+// heroicGame collects what Heroic knows about one installed game.
+type heroicGame struct {
+	title       string
+	store       string
+	installPath string
+}
+
+// heroicCandidates lists the games installed through Heroic, for both the
+// Epic (Legendary) and GOG stores.
+func heroicCandidates() []Candidate {
+	var found []Candidate
+	for _, root := range heroicRoots() {
+		if !isDir(root) {
+			continue
+		}
+
+		games := make(map[string]*heroicGame)
+		readLegendaryInstalled(root, games)
+		readGOGInstalled(root, games)
+
+		for appName, g := range games {
+			c := Candidate{
+				Name:       g.title,
+				Source:     SourceHeroic,
+				AppID:      appName,
+				Store:      g.store,
+				InstallDir: g.installPath,
+			}
+			if prefix := heroicPrefix(root, appName); prefix != "" {
+				c.Prefix = paths.ContractHome(prefix)
+				c.SavePath = prefixSavePath(prefix)
+			} else if g.installPath != "" {
+				c.SavePath = paths.ContractHome(g.installPath)
+			}
+			found = append(found, c)
+		}
+	}
+	return found
+}
+
+// readLegendaryInstalled adds the Epic games listed by Legendary.
+func readLegendaryInstalled(root string, games map[string]*heroicGame) {
+	var installed map[string]struct {
+		Title       string `json:"title"`
+		InstallPath string `json:"install_path"`
+	}
+	if !readJSON(filepath.Join(root, "legendaryConfig", "legendary", "installed.json"), &installed) {
+		return
+	}
+	for appName, g := range installed {
+		games[appName] = &heroicGame{title: g.Title, store: StoreEpic, installPath: g.InstallPath}
+	}
+}
+
+// readGOGInstalled adds the GOG games Heroic has installed. Titles come from
+// the cached library, falling back to the install folder name.
+func readGOGInstalled(root string, games map[string]*heroicGame) {
+	var installed struct {
+		Installed []struct {
+			AppName     string `json:"appName"`
+			InstallPath string `json:"install_path"`
+		} `json:"installed"`
+	}
+	if !readJSON(filepath.Join(root, "gog_store", "installed.json"), &installed) {
+		return
+	}
+
+	titles := make(map[string]string)
+	var library struct {
+		Games []struct {
+			AppName string `json:"app_name"`
+			Title   string `json:"title"`
+		} `json:"games"`
+	}
+	if readJSON(filepath.Join(root, "store_cache", "gog_library.json"), &library) {
+		for _, g := range library.Games {
+			titles[g.AppName] = g.Title
+		}
+	}
+
+	for _, g := range installed.Installed {
+		title := titles[g.AppName]
+		if title == "" {
+			title = filepath.Base(g.InstallPath)
+		}
+		games[g.AppName] = &heroicGame{title: title, store: StoreGOG, installPath: g.InstallPath}
+	}
+}
+
+// heroicPrefix reads the Wine prefix from a game's Heroic settings, or "".
+func heroicPrefix(root, appName string) string {
+	var settings map[string]struct {
+		WinePrefix string `json:"winePrefix"`
+	}
+	if !readJSON(filepath.Join(root, "GamesConfig", appName+".json"), &settings) {
+		return ""
+	}
+	prefix := settings[appName].WinePrefix
+	if prefix == "" {
+		return ""
+	}
+	if strings.HasPrefix(prefix, "~") {
+		if expanded, err := paths.Expand(prefix, paths.Options{}); err == nil {
+			prefix = expanded
+		}
+	}
+	return prefix
+}
+
+// readJSON decodes a JSON file into v, reporting whether it succeeded.
+func readJSON(path string, v interface{}) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
@@ -0,0 +1,139 @@
+package discovery
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+
+	_ "github.com/mattn/go-sqlite3"
+	"gopkg.in/yaml.v3"
+
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/paths"
+)
+
+// lutrisRoot is one Lutris installation: where its game database lives and
+// which directories hold the per-game YAML configs.
+type lutrisRoot struct {
+	db      string
+	configs []string
+}
+
+// lutrisRoots lists the native and Flatpak Lutris installations.
+func lutrisRoots() []lutrisRoot {
+	home := homeDir()
+	flatpak := flatpakRoot("net.lutris.Lutris")
+	return []lutrisRoot{
+		{
+			db: filepath.Join(home, ".local", "share", "lutris", "pga.db"),
+			configs: []string{
+				filepath.Join(home, ".local", "share", "lutris", "games"),
+				filepath.Join(home, ".config", "lutris", "games"),
+			},
+		},
+		{
+			db: filepath.Join(flatpak, "data", "lutris", "pga.db"),
+			configs: []string{
+				filepath.Join(flatpak, "data", "lutris", "games"),
+				filepath.Join(flatpak, "config", "lutris", "games"),
+			},
+		},
+	}
+}
+
+// lutrisConfig is the part of a Lutris game config that matters here.
+type lutrisConfig struct {
+	Game struct {
+		Prefix string `yaml:"prefix"`
+	} `yaml:"game"`
+}
+
+// lutrisCandidates lists the games installed through Lutris.
+func lutrisCandidates() []Candidate {
+	var found []Candidate
+	for _, root := range lutrisRoots() {
+		if _, err := os.Stat(root.db); err != nil {
+			continue
+		}
+		games, err := readLutrisGames(root.db)
+		if err != nil {
+			continue
+		}
+
+		for _, g := range games {
+			c := Candidate{
+				Name:       g.name,
+				Source:     SourceLutris,
+				AppID:      g.slug,
+				InstallDir: g.directory,
+			}
+
+			prefix := lutrisPrefix(root, g.configPath)
+			if prefix == "" && g.runner == "wine" {
+				prefix = g.directory
+			}
+			if prefix != "" {
+				if expanded, err := paths.Expand(prefix, paths.Options{}); err == nil {
+					prefix = expanded
+				}
+				c.Prefix = paths.ContractHome(prefix)
+				c.SavePath = prefixSavePath(prefix)
+			} else if g.directory != "" {
+				c.SavePath = paths.ContractHome(g.directory)
+			}
+			found = append(found, c)
+		}
+	}
+	return found
+}
+
+// lutrisGame is a row of the Lutris games table.
+type lutrisGame struct {
+	name, slug, directory, configPath, runner string
+}
+
+// readLutrisGames reads the installed games from a Lutris pga.db.
+func readLutrisGames(path string) ([]lutrisGame, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT name, slug, COALESCE(directory, ''), COALESCE(configpath, ''), COALESCE(runner, '')
+		FROM games WHERE installed = 1
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var games []lutrisGame
+	for rows.Next() {
+		var g lutrisGame
+		if err := rows.Scan(&g.name, &g.slug, &g.directory, &g.configPath, &g.runner); err != nil {
+			return nil, err
+		}
+		games = append(games, g)
+	}
+	return games, rows.Err()
+}
+
+// lutrisPrefix reads the Wine prefix from a game's YAML config, or "".
+func lutrisPrefix(root lutrisRoot, configPath string) string {
+	if configPath == "" {
+		return ""
+	}
+	for _, dir := range root.configs {
+		data, err := os.ReadFile(filepath.Join(dir, configPath+".yml"))
+		if err != nil {
+			continue
+		}
+		var cfg lutrisConfig
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			continue
+		}
+		return cfg.Game.Prefix
+	}
+	return ""
+}
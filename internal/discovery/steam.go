@@ -0,0 +1,180 @@
+package discovery
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/paths"
+)
+
+// SteamApp is a game installed in a Steam library.
+type SteamApp struct {
+	AppID      string
+	Name       string
+	InstallDir string // absolute path of the game's install directory
+	Library    string // library folder the game is installed in
+}
+
+// SteamLibraries returns the library folders of the Steam installation at
+// root, as listed in libraryfolders.vdf. The installation directory itself
+// is always the first library.
+func SteamLibraries(root string) []string {
+	libraries := []string{root}
+	seen := map[string]bool{filepath.Clean(root): true}
+	add := func(dir string) {
+		dir = filepath.Clean(dir)
+		if dir != "." && !seen[dir] {
+			seen[dir] = true
+			libraries = append(libraries, dir)
+		}
+	}
+
+	for _, file := range []string{
+		filepath.Join(root, "steamapps", "libraryfolders.vdf"),
+		filepath.Join(root, "config", "libraryfolders.vdf"),
+	} {
+		kv, err := ParseVDFFile(file)
+		if err != nil {
+			continue
+		}
+		folders := kv.Child("libraryfolders")
+		if folders == nil {
+			continue
+		}
+		// Current format: "0" { "path" "..." }. Older clients used "1" "path".
+		for _, key := range folders.Order {
+			if path := folders.Children[key].Get("path"); path != "" {
+				add(path)
+			}
+		}
+		for key, value := range folders.Values {
+			if isNumber(key) {
+				add(value)
+			}
+		}
+	}
+	return libraries
+}
+
+// SteamApps lists the games installed in the Steam installation at root by
+// reading the appmanifest_*.acf file of every library.
+func SteamApps(root string) []SteamApp {
+	var apps []SteamApp
+	for _, library := range SteamLibraries(root) {
+		manifests, _ := filepath.Glob(filepath.Join(library, "steamapps", "appmanifest_*.acf"))
+		for _, manifest := range manifests {
+			kv, err := ParseVDFFile(manifest)
+			if err != nil {
+				continue
+			}
+			state := kv.Child("AppState")
+			if state == nil || state.Get("appid") == "" {
+				continue
+			}
+			app := SteamApp{
+				AppID:   state.Get("appid"),
+				Name:    state.Get("name"),
+				Library: library,
+			}
+			if dir := state.Get("installdir"); dir != "" {
+				app.InstallDir = filepath.Join(library, "steamapps", "common", dir)
+			}
+			if app.Name == "" {
+				app.Name = "Steam app " + app.AppID
+			}
+			apps = append(apps, app)
+		}
+	}
+	return apps
+}
+
+// ProtonPrefix returns the Proton prefix of a Steam app, or "" if the game
+// has never been run through Proton. Prefixes live under compatdata in the
+// library the game is installed in.
+func ProtonPrefix(app SteamApp) string {
+	prefix := filepath.Join(app.Library, "steamapps", "compatdata", app.AppID, "pfx")
+	if isDir(prefix) {
+		return prefix
+	}
+	return ""
+}
+
+// steamCloudDir returns the Steam Cloud folder of an app for the first
+// Steam user that has one, or "".
+func steamCloudDir(root, appID string) string {
+	users, err := os.ReadDir(filepath.Join(root, "userdata"))
+	if err != nil {
+		return ""
+	}
+	for _, user := range users {
+		dir := filepath.Join(root, "userdata", user.Name(), appID, "remote")
+		if user.IsDir() && isDir(dir) {
+			return dir
+		}
+	}
+	return ""
+}
+
+// steamCandidates turns the games of every Steam installation into candidates.
+func steamCandidates() []Candidate {
+	var found []Candidate
+	seen := make(map[string]bool)
+	for _, root := range paths.SteamRoots() {
+		for _, app := range SteamApps(root) {
+			if seen[app.AppID] || isSteamTool(app) {
+				continue
+			}
+			seen[app.AppID] = true
+
+			c := Candidate{
+				Name:       app.Name,
+				Source:     SourceSteam,
+				AppID:      app.AppID,
+				Store:      StoreSteam,
+				InstallDir: app.InstallDir,
+			}
+			if prefix := ProtonPrefix(app); prefix != "" {
+				c.Source = SourceProton
+				c.Prefix = paths.ContractHome(prefix)
+				c.SavePath = prefixSavePath(prefix)
+			}
+			if cloud := steamCloudDir(root, app.AppID); cloud != "" {
+				c.SavePath = paths.ContractHome(cloud)
+			}
+			if c.SavePath == "" {
+				c.SavePath = paths.ContractHome(app.InstallDir)
+			}
+			found = append(found, c)
+		}
+	}
+	return found
+}
+
+// isSteamTool reports whether an app is a runtime or compatibility tool
+// rather than a game. Proton builds are named like "Proton 9.0", "Proton
+// Experimental" or "Proton EasyAntiCheat Runtime"; games may start with
+// "Proton" too.
+func isSteamTool(app SteamApp) bool {
+	name := strings.ToLower(app.Name)
+	if version, ok := strings.CutPrefix(name, "proton "); ok {
+		return (version != "" && version[0] >= '0' && version[0] <= '9') ||
+			version == "experimental" || version == "hotfix" || version == "next" ||
+			strings.HasSuffix(version, " runtime")
+	}
+	return strings.HasPrefix(name, "steam linux runtime") ||
+		strings.HasPrefix(name, "steamworks common redistributables")
+}
+
+// isNumber reports whether s is a non-empty string of digits.
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,197 @@
+package discovery
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// KeyValues is a node of Valve's KeyValues text format, used by
+// libraryfolders.vdf and appmanifest_*.acf. Keys are stored lowercased since
+// Steam treats them case-insensitively.
+type KeyValues struct {
+	Values   map[string]string
+	Children map[string]*KeyValues
+	// Order lists child keys in the order they appeared
+	Order []string
+}
+
+func newKeyValues() *KeyValues {
+	return &KeyValues{Values: make(map[string]string), Children: make(map[string]*KeyValues)}
+}
+
+// Get returns the string value stored under key.
+func (kv *KeyValues) Get(key string) string {
+	return kv.Values[strings.ToLower(key)]
+}
+
+// Child returns the block stored under key, or nil.
+func (kv *KeyValues) Child(key string) *KeyValues {
+	return kv.Children[strings.ToLower(key)]
+}
+
+// ParseVDF parses a KeyValues text document. The returned node holds the
+// top-level keys, which is usually a single block such as "libraryfolders".
+func ParseVDF(r io.Reader) (*KeyValues, error) {
+	tokens, err := tokenizeVDF(r)
+	if err != nil {
+		return nil, err
+	}
+
+	root := newKeyValues()
+	stack := []*KeyValues{root}
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		current := stack[len(stack)-1]
+
+		if tok.kind == '}' {
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("vdf: unexpected '}' on line %d", tok.line)
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		if tok.kind != '"' {
+			return nil, fmt.Errorf("vdf: expected a key on line %d", tok.line)
+		}
+		if i+1 >= len(tokens) {
+			return nil, fmt.Errorf("vdf: key %q has no value", tok.text)
+		}
+
+		key := strings.ToLower(tok.text)
+		next := tokens[i+1]
+		i++
+		switch next.kind {
+		case '{':
+			child := newKeyValues()
+			if _, exists := current.Children[key]; !exists {
+				current.Order = append(current.Order, key)
+			}
+			current.Children[key] = child
+			stack = append(stack, child)
+		case '"':
+			current.Values[key] = next.text
+		default:
+			return nil, fmt.Errorf("vdf: key %q has no value on line %d", tok.text, tok.line)
+		}
+	}
+
+	if len(stack) != 1 {
+		return nil, fmt.Errorf("vdf: unterminated block")
+	}
+	return root, nil
+}
+
+// ParseVDFFile parses the KeyValues file at path.
+func ParseVDFFile(path string) (*KeyValues, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	kv, err := ParseVDF(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return kv, nil
+}
+
+// vdfToken is a quoted or bare string ('"') or a brace.
+type vdfToken struct {
+	kind byte
+	text string
+	line int
+}
+
+// tokenizeVDF splits a KeyValues document into tokens, dropping // comments
+// and conditionals such as [$WIN32].
+func tokenizeVDF(r io.Reader) ([]vdfToken, error) {
+	var tokens []vdfToken
+	br := bufio.NewReader(r)
+	line := 1
+
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			return tokens, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case c == '\n':
+			line++
+		case c == ' ' || c == '\t' || c == '\r':
+		case c == '{' || c == '}':
+			tokens = append(tokens, vdfToken{kind: c, line: line})
+		case c == '/':
+			if next, _ := br.Peek(1); len(next) == 1 && next[0] == '/' {
+				if _, err := br.ReadString('\n'); err != nil && err != io.EOF {
+					return nil, err
+				}
+				line++
+			}
+		case c == '[':
+			if _, err := br.ReadString(']'); err != nil {
+				return nil, fmt.Errorf("vdf: unterminated conditional on line %d", line)
+			}
+		case c == '"':
+			s, err := readQuoted(br, &line)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, vdfToken{kind: '"', text: s, line: line})
+		default:
+			// Bare word, terminated by whitespace or a brace
+			var b strings.Builder
+			b.WriteByte(c)
+			for {
+				next, err := br.Peek(1)
+				if err != nil || strings.ContainsRune(" \t\r\n{}\"", rune(next[0])) {
+					break
+				}
+				b.WriteByte(next[0])
+				br.ReadByte()
+			}
+			tokens = append(tokens, vdfToken{kind: '"', text: b.String(), line: line})
+		}
+	}
+}
+
+// readQuoted reads a quoted string whose opening quote has been consumed.
+func readQuoted(br *bufio.Reader, line *int) (string, error) {
+	var b strings.Builder
+	start := *line
+	for {
+		c, err := br.ReadByte()
+		if err != nil {
+			return "", fmt.Errorf("vdf: unterminated string starting on line %d", start)
+		}
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			next, err := br.ReadByte()
+			if err != nil {
+				return "", fmt.Errorf("vdf: unterminated string starting on line %d", start)
+			}
+			switch next {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(next)
+			}
+		case '\n':
+			*line++
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
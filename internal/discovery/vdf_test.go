@@ -0,0 +1,72 @@
+package discovery
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseVDF(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		path  []string // blocks to descend into
+		key   string
+		want  string
+	}{
+		{"quoted", `"AppState" { "appid" "228980" }`, []string{"AppState"}, "appid", "228980"},
+		{"keys ignore case", `"AppState" { "InstallDir" "Game" }`, []string{"appstate"}, "installdir", "Game"},
+		{"bare words", "AppState\n{\n\tname Game\n}", []string{"AppState"}, "name", "Game"},
+		{"escapes", `"a" { "path" "C:\\Games\\Steam" }`, []string{"a"}, "path", `C:\Games\Steam`},
+		{"comments", "// header\n\"a\" { \"k\" \"v\" // trailing\n}", []string{"a"}, "k", "v"},
+		{"conditionals", `"a" { "k" "v" [$WIN32] }`, []string{"a"}, "k", "v"},
+		{"nested", `"libraryfolders" { "0" { "path" "/lib" } }`, []string{"libraryfolders", "0"}, "path", "/lib"},
+		{"later value wins", `"a" { "k" "old" "k" "new" }`, []string{"a"}, "k", "new"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kv, err := ParseVDF(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("ParseVDF() error = %v", err)
+			}
+			for _, block := range tt.path {
+				if kv = kv.Child(block); kv == nil {
+					t.Fatalf("block %q not found", block)
+				}
+			}
+			if got := kv.Get(tt.key); got != tt.want {
+				t.Errorf("Get(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseVDFKeepsChildOrder(t *testing.T) {
+	kv, err := ParseVDF(strings.NewReader(`"f" { "1" { } "0" { } "1" { } }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(kv.Child("f").Order, ","); got != "1,0" {
+		t.Errorf("Order = %s, want 1,0", got)
+	}
+}
+
+func TestParseVDFErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`"a" { "k" "v" } }`, "unexpected '}' on line 1"},
+		{`"a" { "k" "v"`, "unterminated block"},
+		{`"a" { "k" "v`, "unterminated string"},
+		{`"a"`, `key "a" has no value`},
+		{`"a" }`, `key "a" has no value`},
+		{`{ "k" "v" }`, "expected a key"},
+		{`"a" { [$WIN32 }`, "unterminated conditional"},
+	}
+	for _, tt := range tests {
+		_, err := ParseVDF(strings.NewReader(tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseVDF(%q) error = %v, want one containing %q", tt.input, err, tt.want)
+		}
+	}
+}
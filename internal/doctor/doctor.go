@@ -0,0 +1,365 @@
+// Package doctor checks everything the application depends on — the config
+// file, save locations, the backup directory and the database — and explains
+// how to fix what it finds, for when the application fails to start or a
+// backup fails with an error that doesn't say why.
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/backup"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/components"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/config"
+)
+
+// Status is the outcome of one check.
+type Status int
+
+const (
+	StatusOK      Status = iota // nothing to do
+	StatusWarning               // works, but needs attention
+	StatusFailed                // backups or restores will fail until this is fixed
+	StatusSkipped               // could not be checked because an earlier check failed
+)
+
+// String returns a human-readable name for the status.
+func (s Status) String() string {
+	switch s {
+	case StatusOK:
+		return "OK"
+	case StatusWarning:
+		return "Warning"
+	case StatusFailed:
+		return "Failed"
+	case StatusSkipped:
+		return "Skipped"
+	}
+	return "Unknown"
+}
+
+// Check is the result of checking one prerequisite.
+type Check struct {
+	Name   string
+	Status Status
+	Detail string // what was found
+	Hint   string // how to fix it; empty when there is nothing to fix
+}
+
+// Report lists the results of every check, in the order they ran.
+type Report struct {
+	Checks []Check
+}
+
+// Count returns how many checks ended with the given status.
+func (r *Report) Count(status Status) int {
+	n := 0
+	for _, c := range r.Checks {
+		if c.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Healthy reports whether no check failed.
+func (r *Report) Healthy() bool {
+	return r.Count(StatusFailed) == 0
+}
+
+// LowSpace is the free space in the backup directory below which a warning
+// is given.
+const LowSpace = 100 << 20
+
+// Options describes the setup to diagnose.
+type Options struct {
+	// Location is where the config file is.
+	Location config.Location
+	// Config is the loaded configuration with overrides applied, or nil if
+	// it could not be loaded.
+	Config *config.Config
+	// LoadErr is why the configuration could not be loaded.
+	LoadErr error
+}
+
+// run collects checks as they are made.
+type run struct {
+	report Report
+}
+
+func (r *run) add(name string, status Status, detail, hint string) {
+	r.report.Checks = append(r.report.Checks, Check{Name: name, Status: status, Detail: detail, Hint: hint})
+}
+
+// Run checks every prerequisite. Checks that depend on one that failed are
+// reported as skipped rather than left out, so the report always has the
+// same shape.
+func Run(opts Options) *Report {
+	r := &run{}
+	cfg := r.checkConfig(opts)
+
+	if cfg == nil {
+		for _, name := range []string{"Save paths", "Backup directory", "Free space", "Database", "Stale locks", "Orphaned files"} {
+			r.add(name, StatusSkipped, "needs a readable config file", "")
+		}
+		return &r.report
+	}
+
+	// Validate already checks the paths against the disk
+	problems := cfg.Validate()
+	r.checkSavePaths(cfg, problems)
+	backupDir, ok := r.checkBackupDir(cfg, problems)
+	if !ok {
+		for _, name := range []string{"Free space", "Database", "Stale locks", "Orphaned files"} {
+			r.add(name, StatusSkipped, "needs a usable backup directory", "")
+		}
+		return &r.report
+	}
+	r.checkFreeSpace(backupDir)
+	db := r.checkDatabase(backupDir)
+	r.checkLocks(cfg, backupDir, db)
+	r.checkOrphans(cfg, backupDir, db)
+	return &r.report
+}
+
+// checkConfig reports where the config file is and whether it could be
+// read, and returns the configuration if there is one to check further.
+func (r *run) checkConfig(opts Options) *config.Config {
+	path := opts.Location.Path
+	where := path
+	if opts.Location.Source != "" {
+		where = fmt.Sprintf("%s (from %s)", path, opts.Location.Source)
+	}
+
+	if opts.LoadErr != nil {
+		detail := opts.LoadErr.Error()
+		if !strings.Contains(detail, path) {
+			detail = fmt.Sprintf("%s cannot be loaded: %s", where, detail)
+		}
+		r.add("Config file", StatusFailed, detail,
+			"Fix the error in the file, or move it aside and start the application to set it up again")
+		return nil
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		r.add("Config file", StatusFailed, where+" does not exist yet",
+			"Start the application without a command to set up a game and a backup directory")
+		return nil
+	}
+	r.add("Config file", StatusOK, fmt.Sprintf("%s, format version %d", where, opts.Config.Version), "")
+	return opts.Config
+}
+
+// fieldProblem returns what Validate found wrong with a field, if anything.
+func fieldProblem(problems config.ValidationErrors, game int, field string) (string, bool) {
+	for _, p := range problems {
+		if p.Game == game && p.Field == field {
+			return p.Message, true
+		}
+	}
+	return "", false
+}
+
+// checkSavePaths checks that every game's save exists and can be read, and
+// that no save contains the backup directory.
+func (r *run) checkSavePaths(cfg *config.Config, problems config.ValidationErrors) {
+	if len(cfg.Games) == 0 {
+		r.add("Save paths", StatusFailed, "no game is configured",
+			"Start the application to add a game, or add one to \"games\" in the config file")
+		return
+	}
+	for i, g := range cfg.Games {
+		name := "Save path of " + g.Name
+		if msg, ok := fieldProblem(problems, i, "save_path"); ok {
+			hint := fmt.Sprintf("Switch to %s and use Settings → Change Save Path, or correct games[%d].save_path in the config file", g.Name, i)
+			if strings.Contains(msg, "cannot be read") {
+				hint = "Give your user read access to the save, or " + strings.ToLower(hint[:1]) + hint[1:]
+			}
+			r.add(name, StatusFailed, msg, hint)
+			continue
+		}
+		save, _ := g.ResolveSavePath()
+		r.add(name, StatusOK, save+" is readable", "")
+	}
+}
+
+// checkBackupDir checks that backups can be written, and returns the
+// resolved backup directory.
+func (r *run) checkBackupDir(cfg *config.Config, problems config.ValidationErrors) (string, bool) {
+	if msg, ok := fieldProblem(problems, -1, "backup_dir"); ok {
+		r.add("Backup directory", StatusFailed, msg,
+			"Use Settings → Change Backup Directory or correct backup_dir in the config file, and make sure your user can write to it")
+		return "", false
+	}
+	dir, _ := cfg.ResolveBackupDir()
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		r.add("Backup directory", StatusOK, dir+" will be created with the first backup", "")
+		return dir, true
+	}
+	r.add("Backup directory", StatusOK, dir+" is writable", "")
+	return dir, true
+}
+
+// checkFreeSpace reports the space left on the disk holding the backups.
+func (r *run) checkFreeSpace(backupDir string) {
+	// The directory may not exist yet; ask about the disk it will be on
+	dir := backupDir
+	for {
+		if _, err := os.Stat(dir); err == nil || filepath.Dir(dir) == dir {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+
+	free, err := freeSpace(dir)
+	if err != nil {
+		r.add("Free space", StatusSkipped, fmt.Sprintf("cannot be determined: %v", err), "")
+		return
+	}
+	detail := components.FormatSize(int64(free)) + " available in " + dir
+	if free < LowSpace {
+		r.add("Free space", StatusWarning, detail,
+			"Free up space, prune old backups, or move the backup directory to a larger disk")
+		return
+	}
+	r.add("Free space", StatusOK, detail, "")
+}
+
+// checkDatabase checks that the database can be opened, that this version
+// of the application understands its schema and that SQLite finds no
+// damage in it. It returns what was found, or nil if the database could not
+// be read.
+func (r *run) checkDatabase(backupDir string) *backup.DatabaseInfo {
+	info, err := backup.InspectDatabase(backupDir)
+	if err != nil {
+		r.add("Database", StatusFailed, fmt.Sprintf("cannot be opened: %v", err),
+			"Check the permissions of backups.db; if the file is damaged, put back a backups.db.v*.bak copy from an earlier upgrade")
+		return nil
+	}
+
+	switch {
+	case !info.Exists:
+		r.add("Database", StatusOK, info.Path+" will be created when the application starts", "")
+	case info.Version > backup.SchemaVersion:
+		r.add("Database", StatusFailed,
+			fmt.Sprintf("schema version %d is newer than this version of the application supports (%d)", info.Version, backup.SchemaVersion),
+			"Upgrade the application to the version that last used this backup directory")
+	case len(info.Problems) > 0:
+		detail := fmt.Sprintf("integrity check found %d problem(s): %s", len(info.Problems), info.Problems[0])
+		r.add("Database", StatusFailed, detail,
+			"Put back a backups.db.v*.bak copy from an earlier upgrade, or rebuild the records with Settings → Reconcile Backups With Disk")
+	case info.Version < backup.SchemaVersion:
+		r.add("Database", StatusWarning,
+			fmt.Sprintf("schema version %d passes the integrity check and will be upgraded to %d on the next start", info.Version, backup.SchemaVersion),
+			"Nothing to do; a copy is kept as backups.db.v<N>-<time>.bak before upgrading")
+	default:
+		r.add("Database", StatusOK, fmt.Sprintf("schema version %d, integrity check passed", info.Version), "")
+	}
+	return info
+}
+
+// checkLocks looks for another process holding the database and for files
+// left behind by operations that were interrupted.
+func (r *run) checkLocks(cfg *config.Config, backupDir string, db *backup.DatabaseInfo) {
+	var found []string
+	var hints []string
+	if db != nil && db.Locked {
+		found = append(found, "the database is locked by another process")
+		hints = append(hints, "close other running copies of the application")
+	}
+	if db != nil && db.HotJournal {
+		found = append(found, "an interrupted write left backups.db-journal")
+		hints = append(hints, "leave the journal in place; SQLite rolls the write back the next time the database is opened")
+	}
+
+	leftovers, err := backup.Leftovers(backupDir)
+	if err == nil && len(leftovers) > 0 {
+		found = append(found, fmt.Sprintf("%d unfinished object(s) in %s", len(leftovers), filepath.Dir(leftovers[0])))
+		hints = append(hints, "delete the .incoming-* files when the application is not running")
+	}
+
+	var restores []string
+	for _, g := range cfg.Games {
+		save, err := g.ResolveSavePath()
+		if err != nil {
+			continue
+		}
+		if files, err := backup.RestoreLeftovers(save); err == nil {
+			restores = append(restores, files...)
+		}
+	}
+	if len(restores) > 0 {
+		found = append(found, "an interrupted restore left "+strings.Join(restores, ", "))
+		hints = append(hints, "a .old-* folder holds the save from before that restore, so copy back anything you need before deleting these")
+	}
+
+	if len(found) == 0 {
+		r.add("Stale locks", StatusOK, "no locks or leftover files", "")
+		return
+	}
+	hint := strings.Join(hints, "; ")
+	r.add("Stale locks", StatusWarning, strings.Join(found, "; "), strings.ToUpper(hint[:1])+hint[1:])
+}
+
+// checkOrphans compares the database with the backup directory for every
+// game, as Settings → Reconcile Backups With Disk does for one.
+func (r *run) checkOrphans(cfg *config.Config, backupDir string, info *backup.DatabaseInfo) {
+	skip := ""
+	switch {
+	case info == nil || len(info.Problems) > 0 || info.Version > backup.SchemaVersion:
+		skip = "needs a healthy database"
+	case !info.Exists:
+		skip = "there are no backups yet"
+	case info.Version < backup.SchemaVersion:
+		skip = "needs the database to be upgraded first"
+	case info.Locked:
+		skip = "the database is in use by another process"
+	}
+	if skip != "" {
+		r.add("Orphaned files", StatusSkipped, skip, "")
+		return
+	}
+
+	db, err := backup.InitDB(backupDir, "")
+	if err != nil {
+		r.add("Orphaned files", StatusSkipped, fmt.Sprintf("cannot open the database: %v", err), "")
+		return
+	}
+	defer db.Close()
+
+	missing, orphans := 0, 0
+	unused := make(map[string]bool)
+	var games []string
+	for i := range cfg.Games {
+		g := &cfg.Games[i]
+		strays := ""
+		if cfg.OwnsLegacyBackups(g) {
+			strays = backupDir
+		}
+		report, err := db.Reconcile(g.ID, strays)
+		if err != nil {
+			r.add("Orphaned files", StatusFailed, err.Error(), "")
+			return
+		}
+		missing += len(report.Missing)
+		orphans += len(report.Orphans)
+		for _, id := range report.UnusedObjects {
+			unused[id] = true
+		}
+		if len(report.Missing) > 0 || len(report.Orphans) > 0 {
+			games = append(games, g.Name)
+		}
+	}
+
+	if missing == 0 && orphans == 0 && len(unused) == 0 {
+		r.add("Orphaned files", StatusOK, "the database and the backup directory agree", "")
+		return
+	}
+	detail := fmt.Sprintf("%d record(s) with missing data, %d stray backup file(s), %d unused object(s)", missing, orphans, len(unused))
+	if len(games) > 0 {
+		detail += " (" + strings.Join(games, ", ") + ")"
+	}
+	r.add("Orphaned files", StatusWarning, detail,
+		"Use Settings → Reconcile Backups With Disk for each game to adopt or remove them")
+}
@@ -0,0 +1,143 @@
+package doctor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/backup"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/config"
+)
+
+// testEnv is a config file, a save and a backup directory in a temporary
+// directory, for one game, hades.
+type testEnv struct {
+	dir  string
+	opts Options
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	cfg, _, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	save := filepath.Join(dir, "hades.sav")
+	write(t, save)
+	cfg.AddGame("Hades", save)
+	cfg.BackupDir = filepath.Join(dir, "backups")
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+	return &testEnv{dir: dir, opts: Options{Location: config.Location{Path: path}, Config: cfg}}
+}
+
+// initDB creates the database with one backup of hades.
+func (e *testEnv) initDB(t *testing.T) *backup.DB {
+	t.Helper()
+	db, err := backup.InitDB(e.opts.Config.BackupDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	g := &e.opts.Config.Games[0]
+	if _, err := db.CreateBackup(g.SavePath, "first", backup.CreateOptions{GameID: g.ID}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func write(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(filepath.Base(path)), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	const (
+		ok      = StatusOK
+		warning = StatusWarning
+		failed  = StatusFailed
+		skipped = StatusSkipped
+	)
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, e *testEnv)
+		want  map[string]Status // by check name; Free space depends on the machine
+	}{
+		{"config cannot be loaded", func(t *testing.T, e *testEnv) {
+			e.opts.Config, e.opts.LoadErr = nil, errors.New("invalid character '}'")
+		}, map[string]Status{"Config file": failed, "Save paths": skipped, "Backup directory": skipped,
+			"Free space": skipped, "Database": skipped, "Stale locks": skipped, "Orphaned files": skipped}},
+		{"no config file", func(t *testing.T, e *testEnv) {
+			os.Remove(e.opts.Location.Path)
+		}, map[string]Status{"Config file": failed, "Save paths": skipped, "Backup directory": skipped,
+			"Database": skipped, "Stale locks": skipped, "Orphaned files": skipped}},
+		{"first start", nil, map[string]Status{"Config file": ok, "Save path of Hades": ok, "Backup directory": ok,
+			"Database": ok, "Stale locks": ok, "Orphaned files": skipped}},
+		{"healthy", func(t *testing.T, e *testEnv) { e.initDB(t) }, map[string]Status{"Config file": ok,
+			"Save path of Hades": ok, "Backup directory": ok, "Database": ok, "Stale locks": ok, "Orphaned files": ok}},
+		{"no games", func(t *testing.T, e *testEnv) { e.opts.Config.Games = nil }, map[string]Status{
+			"Save paths": failed, "Backup directory": ok}},
+		{"save missing", func(t *testing.T, e *testEnv) {
+			os.Remove(e.opts.Config.Games[0].SavePath)
+		}, map[string]Status{"Save path of Hades": failed, "Backup directory": ok, "Database": ok}},
+		{"backup directory is a file", func(t *testing.T, e *testEnv) {
+			write(t, e.opts.Config.BackupDir)
+		}, map[string]Status{"Save path of Hades": ok, "Backup directory": failed,
+			"Free space": skipped, "Database": skipped, "Stale locks": skipped, "Orphaned files": skipped}},
+		{"newer database", func(t *testing.T, e *testEnv) {
+			if _, err := e.initDB(t).Exec("UPDATE schema_version SET version = ?", backup.SchemaVersion+1); err != nil {
+				t.Fatal(err)
+			}
+		}, map[string]Status{"Database": failed, "Orphaned files": skipped}},
+		{"interrupted store", func(t *testing.T, e *testEnv) {
+			e.initDB(t)
+			write(t, filepath.Join(e.opts.Config.BackupDir, "objects", ".incoming-123"))
+		}, map[string]Status{"Database": ok, "Stale locks": warning}},
+		{"interrupted restore", func(t *testing.T, e *testEnv) {
+			write(t, filepath.Join(e.dir, ".hades.sav.old-123"))
+		}, map[string]Status{"Stale locks": warning}},
+		{"stray backup file", func(t *testing.T, e *testEnv) {
+			e.initDB(t)
+			write(t, filepath.Join(e.opts.Config.BackupDir, "old.sav"))
+		}, map[string]Status{"Database": ok, "Orphaned files": warning}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			if tt.setup != nil {
+				tt.setup(t, e)
+			}
+			report := Run(e.opts)
+
+			got := make(map[string]Check)
+			for _, c := range report.Checks {
+				got[c.Name] = c
+			}
+			for name, want := range tt.want {
+				c, ok := got[name]
+				if !ok {
+					t.Errorf("no %q check in %+v", name, report.Checks)
+					continue
+				}
+				if c.Status != want {
+					t.Errorf("%s: %s (%s), want %s", name, c.Status, c.Detail, want)
+				}
+				if (c.Status == failed || c.Status == warning) && c.Hint == "" {
+					t.Errorf("%s: %s without a hint", name, c.Status)
+				}
+			}
+			if healthy := report.Count(failed) == 0; report.Healthy() != healthy {
+				t.Errorf("Healthy() = %v with %d failed check(s)", report.Healthy(), report.Count(failed))
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package doctor
+
+import "golang.org/x/sys/windows"
+
+// freeSpace returns the bytes available to the user on the disk holding path.
+func freeSpace(path string) (uint64, error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var available uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &available, nil, nil); err != nil {
+		return 0, err
+	}
+	return available, nil
+}
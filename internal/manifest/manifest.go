@@ -0,0 +1,97 @@
+// Package manifest reads save-location manifests in the format maintained by
+// the Ludusavi project and turns their entries into game profiles.
+//
+// A manifest is a YAML map from game name to where that game keeps its
+// files. Paths use placeholders such as <base>, <winAppData> or <xdgData>
+// and may be limited to an operating system or store:
+//
+//	Some Game:
+//	  files:
+//	    <winAppData>/Some Game/Saves:
+//	      tags: [save]
+//	      when:
+//	        - os: windows
+//	  installDir:
+//	    Some Game: {}
+//	  steam:
+//	    id: 12345
+package manifest
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest maps game names to their entries.
+type Manifest map[string]Game
+
+// Game is the manifest entry of one game.
+type Game struct {
+	Files      map[string]File     `yaml:"files"`
+	InstallDir map[string]struct{} `yaml:"installDir"`
+	Steam      *StoreID            `yaml:"steam"`
+	GOG        *StoreID            `yaml:"gog"`
+}
+
+// File describes one path of a game's files.
+type File struct {
+	Tags []string    `yaml:"tags"`
+	When []Condition `yaml:"when"`
+}
+
+// Condition limits a file to an operating system ("windows", "linux" or
+// "mac") and/or a store ("steam", "epic", "gog", ...). Empty fields match
+// anything.
+type Condition struct {
+	OS    string `yaml:"os"`
+	Store string `yaml:"store"`
+}
+
+// StoreID is a game's ID in a store.
+type StoreID struct {
+	ID int64 `yaml:"id"`
+}
+
+// Load reads a manifest from a YAML file.
+func Load(path string) (Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return m, nil
+}
+
+// isSave reports whether the file holds saves. Entries tagged only as
+// configuration are left out; untagged entries are kept.
+func (f File) isSave() bool {
+	if len(f.Tags) == 0 {
+		return true
+	}
+	for _, tag := range f.Tags {
+		if tag == "save" {
+			return true
+		}
+	}
+	return false
+}
+
+// appliesTo reports whether the file's conditions allow it on the given OS
+// and store. A condition naming a store never matches an unknown store.
+func (f File) appliesTo(goos, store string) bool {
+	if len(f.When) == 0 {
+		return true
+	}
+	for _, c := range f.When {
+		if (c.OS == "" || c.OS == goos) && (c.Store == "" || c.Store == store) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.yaml")
+	data := `
+Some Game:
+  files:
+    <winAppData>/Some Game/Saves:
+      tags: [save]
+      when:
+        - os: windows
+          store: steam
+  installDir:
+    Some Game: {}
+  steam:
+    id: 12345
+  gog:
+    id: 67890
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, ok := m["Some Game"]
+	if !ok {
+		t.Fatalf("Load() = %+v, want an entry for Some Game", m)
+	}
+	file, ok := g.Files["<winAppData>/Some Game/Saves"]
+	if !ok || len(file.When) != 1 || file.When[0] != (Condition{OS: "windows", Store: "steam"}) {
+		t.Errorf("files = %+v, want the save folder for Windows on Steam", g.Files)
+	}
+	if _, ok := g.InstallDir["Some Game"]; !ok {
+		t.Errorf("installDir = %v, want Some Game", g.InstallDir)
+	}
+	if g.Steam == nil || g.Steam.ID != 12345 || g.GOG == nil || g.GOG.ID != 67890 {
+		t.Errorf("store IDs = %+v, %+v; want 12345 and 67890", g.Steam, g.GOG)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Load(filepath.Join(dir, "missing.yaml")); !os.IsNotExist(err) {
+		t.Errorf("Load(missing) error = %v, want a not-exist error", err)
+	}
+
+	path := filepath.Join(dir, "bad.yaml")
+	if err := os.WriteFile(path, []byte("Some Game: [not, a, map]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("Load(bad) error = %v, want one naming the file", err)
+	}
+}
+
+func TestFileIsSave(t *testing.T) {
+	tests := []struct {
+		tags []string
+		want bool
+	}{
+		{nil, true},
+		{[]string{"save"}, true},
+		{[]string{"config", "save"}, true},
+		{[]string{"config"}, false},
+	}
+	for _, tt := range tests {
+		if got := (File{Tags: tt.tags}).isSave(); got != tt.want {
+			t.Errorf("isSave() with tags %v = %v, want %v", tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestFileAppliesTo(t *testing.T) {
+	tests := []struct {
+		when  []Condition
+		os    string
+		store string
+		want  bool
+	}{
+		{nil, "linux", "", true},
+		{[]Condition{{OS: "windows"}}, "windows", "steam", true},
+		{[]Condition{{OS: "windows"}}, "linux", "steam", false},
+		{[]Condition{{Store: "steam"}}, "linux", "steam", true},
+		{[]Condition{{Store: "steam"}}, "linux", "", false},
+		{[]Condition{{OS: "windows", Store: "gog"}}, "windows", "steam", false},
+		{[]Condition{{OS: "mac"}, {OS: "linux"}}, "linux", "", true},
+	}
+	for _, tt := range tests {
+		if got := (File{When: tt.when}).appliesTo(tt.os, tt.store); got != tt.want {
+			t.Errorf("appliesTo(%q, %q) with %+v = %v, want %v", tt.os, tt.store, tt.when, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,419 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bmatcuk/doublestar/v4"
+
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/discovery"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/paths"
+)
+
+// Profile is a game profile suggested by a manifest for this machine.
+type Profile struct {
+	Name string
+
+	// SavePath and Prefix use the same syntax as the configuration, so
+	// SavePath may refer to Prefix with <prefix>.
+	SavePath string
+	Prefix   string
+
+	// Include lists glob patterns, relative to SavePath, for games whose
+	// saves are spread over several places inside it.
+	Include []string
+}
+
+// Resolve returns profiles for the manifest games whose saves exist on this
+// machine, sorted by name. Installed games are matched to the manifest by
+// store ID, install folder or name, which lets paths inside the install
+// directory and Wine/Proton prefix be resolved. Other games are checked
+// against the native locations in the home directory.
+func (m Manifest) Resolve(installed []discovery.Candidate) []Profile {
+	idx := m.index()
+
+	var profiles []Profile
+	done := make(map[string]bool)
+	for _, c := range installed {
+		name := idx.match(c)
+		if name == "" || done[name] {
+			continue
+		}
+		done[name] = true
+		if p, ok := m[name].resolve(name, targetFor(c)); ok {
+			profiles = append(profiles, p)
+		}
+	}
+
+	native := nativeTarget()
+	for name, g := range m {
+		if done[name] {
+			continue
+		}
+		if p, ok := g.resolve(name, native); ok {
+			profiles = append(profiles, p)
+		}
+	}
+
+	sort.Slice(profiles, func(i, j int) bool {
+		return strings.ToLower(profiles[i].Name) < strings.ToLower(profiles[j].Name)
+	})
+	return profiles
+}
+
+// index finds manifest games by store ID, install folder and name.
+type index struct {
+	steam, gog, installDir, name map[string]string
+}
+
+func (m Manifest) index() index {
+	idx := index{
+		steam:      make(map[string]string),
+		gog:        make(map[string]string),
+		installDir: make(map[string]string),
+		name:       make(map[string]string),
+	}
+	for name, g := range m {
+		if g.Steam != nil && g.Steam.ID != 0 {
+			idx.steam[strconv.FormatInt(g.Steam.ID, 10)] = name
+		}
+		if g.GOG != nil && g.GOG.ID != 0 {
+			idx.gog[strconv.FormatInt(g.GOG.ID, 10)] = name
+		}
+		for dir := range g.InstallDir {
+			idx.installDir[strings.ToLower(dir)] = name
+		}
+		idx.name[normalizeName(name)] = name
+	}
+	return idx
+}
+
+// match returns the manifest name of an installed game, or "".
+func (idx index) match(c discovery.Candidate) string {
+	switch c.Store {
+	case discovery.StoreSteam:
+		if name := idx.steam[c.AppID]; name != "" {
+			return name
+		}
+	case discovery.StoreGOG:
+		if name := idx.gog[c.AppID]; name != "" {
+			return name
+		}
+	}
+	if c.InstallDir != "" {
+		if name := idx.installDir[strings.ToLower(filepath.Base(c.InstallDir))]; name != "" {
+			return name
+		}
+	}
+	return idx.name[normalizeName(c.Name)]
+}
+
+// normalizeName reduces a game name to lowercase letters and digits, so that
+// punctuation and trademark signs don't prevent a match.
+func normalizeName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// target is what manifest paths are resolved against for one game.
+type target struct {
+	os    string // "windows", "linux" or "mac"
+	store string
+
+	prefix    string // as configured
+	prefixAbs string
+	user      string // Windows user name inside the prefix
+
+	base string // absolute install directory, if known
+}
+
+// targetFor describes an installed game. Games with a Wine or Proton prefix
+// are Windows games regardless of the host.
+func targetFor(c discovery.Candidate) target {
+	t := nativeTarget()
+	t.store = c.Store
+	t.base = c.InstallDir
+	if c.Prefix != "" {
+		abs, err := paths.Expand(c.Prefix, paths.Options{})
+		if err == nil {
+			t.os = "windows"
+			t.prefix = c.Prefix
+			t.prefixAbs = abs
+			t.user = prefixUser(abs)
+		}
+	}
+	return t
+}
+
+// nativeTarget describes a game running natively on this machine.
+func nativeTarget() target {
+	switch runtime.GOOS {
+	case "windows":
+		return target{os: "windows"}
+	case "darwin":
+		return target{os: "mac"}
+	}
+	return target{os: "linux"}
+}
+
+// prefixUser returns the user whose profile a Wine prefix keeps saves in:
+// steamuser under Proton, otherwise the name of the local user.
+func prefixUser(prefix string) string {
+	users := filepath.Join(prefix, "drive_c", "users")
+	candidates := []string{"steamuser", os.Getenv("USER"), os.Getenv("USERNAME")}
+	for _, user := range candidates {
+		if user == "" {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(users, user)); err == nil && info.IsDir() {
+			return user
+		}
+	}
+	return "steamuser"
+}
+
+// location is a place a game's saves were found: a file or directory, or
+// a glob pattern below a directory.
+type location struct {
+	path    string // absolute
+	pattern string // relative to path; "" for the whole file or directory
+	isFile  bool
+}
+
+// resolve turns the game's save entries into a profile. It fails if none of
+// them exist on this machine.
+func (g Game) resolve(name string, t target) (Profile, bool) {
+	var locations []location
+	for path, file := range g.Files {
+		if !file.isSave() || !file.appliesTo(t.os, t.store) {
+			continue
+		}
+		if loc, ok := t.locate(path); ok {
+			locations = append(locations, loc)
+		}
+	}
+	if len(locations) == 0 {
+		return Profile{}, false
+	}
+
+	p := Profile{Name: name, Prefix: t.prefix}
+	if len(locations) == 1 && locations[0].pattern == "" {
+		p.SavePath = t.configPath(locations[0].path)
+		return p, true
+	}
+
+	// Several places: back up their common directory, limited to them
+	dirs := make([]string, len(locations))
+	for i, loc := range locations {
+		if loc.isFile {
+			loc.pattern = filepath.Base(loc.path)
+			loc.path = filepath.Dir(loc.path)
+			locations[i] = loc
+		}
+		dirs[i] = loc.path
+	}
+	root := commonDir(dirs)
+	if filepath.Dir(root) == root {
+		// Spread over the whole filesystem; too broad to back up
+		return Profile{}, false
+	}
+
+	seen := make(map[string]bool)
+	for _, loc := range locations {
+		rel, err := filepath.Rel(root, loc.path)
+		if err != nil {
+			return Profile{}, false
+		}
+		include := filepath.ToSlash(rel)
+		if loc.pattern != "" {
+			include = filepath.ToSlash(filepath.Join(rel, loc.pattern))
+		}
+		if include == "." {
+			// One location is the whole directory
+			p.Include = nil
+			break
+		}
+		if !seen[include] {
+			seen[include] = true
+			p.Include = append(p.Include, include)
+		}
+	}
+	sort.Strings(p.Include)
+	p.SavePath = t.configPath(root)
+	return p, true
+}
+
+// locate resolves a manifest path and checks that something exists there.
+func (t target) locate(path string) (location, bool) {
+	translated, ok := t.translate(path)
+	if !ok {
+		return location{}, false
+	}
+	abs, err := paths.Expand(translated, paths.Options{Prefix: t.prefix})
+	if err != nil {
+		return location{}, false
+	}
+
+	matches, err := doublestar.FilepathGlob(abs)
+	if err != nil || len(matches) == 0 {
+		return location{}, false
+	}
+
+	if !strings.ContainsAny(abs, "*?[{") {
+		info, err := os.Stat(abs)
+		if err != nil {
+			return location{}, false
+		}
+		return location{path: abs, isFile: !info.IsDir()}, true
+	}
+
+	base, pattern := doublestar.SplitPattern(filepath.ToSlash(abs))
+	if !doublestar.ValidatePattern(pattern) {
+		return location{}, false
+	}
+	return location{path: filepath.FromSlash(base), pattern: pattern}, true
+}
+
+var placeholderPattern = regexp.MustCompile(`<[A-Za-z]+>`)
+
+// translate rewrites the manifest placeholders in path into configuration
+// path syntax. It fails if a placeholder has no meaning for the target, such
+// as <base> for a game that isn't installed or <winAppData> on Linux.
+func (t target) translate(path string) (string, bool) {
+	ok := true
+	out := placeholderPattern.ReplaceAllStringFunc(path, func(ph string) string {
+		value, known := t.placeholder(ph[1 : len(ph)-1])
+		if !known {
+			ok = false
+		}
+		return value
+	})
+	return out, ok
+}
+
+// placeholder returns the value of one manifest placeholder.
+func (t target) placeholder(name string) (string, bool) {
+	switch name {
+	case "base":
+		return t.base, t.base != ""
+	case "game":
+		return filepath.Base(t.base), t.base != ""
+	case "root":
+		return storeRoot(t.base, t.store), t.base != ""
+	case "storeUserId":
+		return "*", true
+	case "osUserName":
+		if t.prefixAbs != "" {
+			return t.user, true
+		}
+		user := os.Getenv("USER")
+		if user == "" {
+			user = os.Getenv("USERNAME")
+		}
+		return user, user != ""
+	case "home":
+		if t.prefixAbs != "" {
+			return "<prefix>/drive_c/users/" + t.user, true
+		}
+		return "<home>", true
+	case "xdgData", "xdgConfig":
+		return "<" + name + ">", t.os == "linux" || t.os == "mac"
+	}
+
+	if t.os != "windows" {
+		return "", false
+	}
+	if t.prefixAbs != "" {
+		profile := "<prefix>/drive_c/users/" + t.user
+		switch name {
+		case "winAppData":
+			return profile + "/AppData/Roaming", true
+		case "winLocalAppData":
+			return profile + "/AppData/Local", true
+		case "winLocalAppDataLow":
+			return profile + "/AppData/LocalLow", true
+		case "winDocuments":
+			return profile + "/Documents", true
+		case "winPublic":
+			return "<prefix>/drive_c/users/Public", true
+		case "winProgramData":
+			return "<prefix>/drive_c/ProgramData", true
+		case "winDir":
+			return "<prefix>/drive_c/windows", true
+		}
+		return "", false
+	}
+	switch name {
+	case "winAppData":
+		return "${APPDATA}", true
+	case "winLocalAppData":
+		return "${LOCALAPPDATA}", true
+	case "winLocalAppDataLow":
+		return "<home>/AppData/LocalLow", true
+	case "winDocuments":
+		return "<home>/Documents", true
+	case "winPublic":
+		return "${PUBLIC}", true
+	case "winProgramData":
+		return "${PROGRAMDATA}", true
+	case "winDir":
+		return "${WINDIR}", true
+	}
+	return "", false
+}
+
+// storeRoot returns the folder a store installs games into: the Steam
+// library for Steam games, otherwise the parent of the install directory.
+func storeRoot(base, store string) string {
+	parent := filepath.Dir(base)
+	if store == discovery.StoreSteam && filepath.Base(parent) == "common" &&
+		filepath.Base(filepath.Dir(parent)) == "steamapps" {
+		return filepath.Dir(filepath.Dir(parent))
+	}
+	return parent
+}
+
+// configPath rewrites an absolute path into configuration syntax, relative
+// to the prefix or home directory where possible.
+func (t target) configPath(abs string) string {
+	if t.prefixAbs != "" && within(abs, t.prefixAbs) {
+		rel, _ := filepath.Rel(t.prefixAbs, abs)
+		if rel == "." {
+			return "<prefix>"
+		}
+		return "<prefix>/" + filepath.ToSlash(rel)
+	}
+	return paths.ContractHome(abs)
+}
+
+// commonDir returns the deepest directory containing every one of dirs.
+func commonDir(dirs []string) string {
+	common := dirs[0]
+	for _, dir := range dirs[1:] {
+		for !within(dir, common) {
+			parent := filepath.Dir(common)
+			if parent == common {
+				return common
+			}
+			common = parent
+		}
+	}
+	return common
+}
+
+// within reports whether path is dir or inside it.
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,178 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/discovery"
+)
+
+// touch creates a file below root, with its directories.
+func touch(t *testing.T, root, path string) {
+	t.Helper()
+	path = filepath.Join(root, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(path), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const testManifest = `
+Alpha Quest:
+  files:
+    <winAppData>/Alpha:
+      tags: [save]
+      when:
+        - os: windows
+    <winDocuments>/My Games/Alpha/*.dat:
+      tags: [save]
+    <winAppData>/Alpha/config.ini:
+      tags: [config]
+  steam:
+    id: 200
+Zeta™ Game:
+  files:
+    <base>/saves:
+      when:
+        - store: steam
+Folder Game:
+  files:
+    <base>/profile.sav: {}
+  installDir:
+    folder-game: {}
+Native Game:
+  files:
+    <xdgData>/nativegame/*.dat: {}
+    <xdgData>/nativegame/cfg.ini:
+      tags: [config]
+Single:
+  files:
+    <xdgData>/single/only.sav:
+Windows Only:
+  files:
+    <winAppData>/Foo: {}
+Missing:
+  files:
+    <home>/nothing: {}
+`
+
+func TestResolve(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("native locations are only checked on Linux")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USER", "me")
+	t.Setenv("XDG_DATA_HOME", "")
+
+	pfx := "steam/steamapps/compatdata/200/pfx/drive_c/users/steamuser/"
+	touch(t, home, pfx+"AppData/Roaming/Alpha/save1.sav")
+	touch(t, home, pfx+"AppData/Roaming/Alpha/config.ini")
+	touch(t, home, pfx+"Documents/My Games/Alpha/profile.dat")
+	touch(t, home, "steam/steamapps/common/Zeta/saves/a.sav")
+	touch(t, home, "games/folder-game/profile.sav")
+	touch(t, home, ".local/share/nativegame/slot.dat")
+	touch(t, home, ".local/share/nativegame/cfg.ini")
+	touch(t, home, ".local/share/single/only.sav")
+	// Windows Only's folder exists, but only in a prefix it isn't matched to
+	touch(t, home, "other/pfx/drive_c/users/steamuser/AppData/Roaming/Foo/x.sav")
+
+	path := filepath.Join(home, "manifest.yaml")
+	if err := os.WriteFile(path, []byte(testManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	installed := []discovery.Candidate{
+		// Matched by Steam ID, despite the different name
+		{Name: "Alpha", Store: discovery.StoreSteam, AppID: "200",
+			InstallDir: filepath.Join(home, "steam/steamapps/common/Alpha"), Prefix: "~/steam/steamapps/compatdata/200/pfx"},
+		// Matched by name, ignoring the trademark sign
+		{Name: "Zeta Game", Store: discovery.StoreSteam, AppID: "100",
+			InstallDir: filepath.Join(home, "steam/steamapps/common/Zeta")},
+		// Matched by install folder
+		{Name: "Something Else", Source: discovery.SourceLutris, InstallDir: filepath.Join(home, "games/folder-game")},
+		{Name: "Unknown", Prefix: "~/other/pfx"},
+	}
+	want := []Profile{
+		{Name: "Alpha Quest", SavePath: "<prefix>/drive_c/users/steamuser", Prefix: "~/steam/steamapps/compatdata/200/pfx",
+			Include: []string{"AppData/Roaming/Alpha", "Documents/My Games/Alpha/*.dat"}},
+		{Name: "Folder Game", SavePath: "~/games/folder-game/profile.sav"},
+		{Name: "Native Game", SavePath: "~/.local/share/nativegame", Include: []string{"*.dat"}},
+		{Name: "Single", SavePath: "~/.local/share/single/only.sav"},
+		{Name: "Zeta™ Game", SavePath: "~/steam/steamapps/common/Zeta/saves"},
+	}
+	if got := m.Resolve(installed); !reflect.DeepEqual(got, want) {
+		t.Errorf("Resolve() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	prefix := target{os: "windows", store: discovery.StoreSteam, prefix: "~/pfx", prefixAbs: "/home/me/pfx", user: "steamuser",
+		base: "/lib/steamapps/common/Game"}
+	linux := target{os: "linux", base: "/games/Game"}
+	windows := target{os: "windows"}
+
+	tests := []struct {
+		name   string
+		target target
+		path   string
+		want   string // "" if the path can't be used
+	}{
+		{"prefix app data", prefix, "<winAppData>/Game", "<prefix>/drive_c/users/steamuser/AppData/Roaming/Game"},
+		{"prefix documents", prefix, "<winDocuments>/My Games", "<prefix>/drive_c/users/steamuser/Documents/My Games"},
+		{"prefix home", prefix, "<home>/Saved Games", "<prefix>/drive_c/users/steamuser/Saved Games"},
+		{"prefix user name", prefix, "C:/Users/<osUserName>", "C:/Users/steamuser"},
+		{"Steam library root", prefix, "<root>/userdata", "/lib/userdata"},
+		{"install directory", linux, "<base>/saves", "/games/Game/saves"},
+		{"install folder name", linux, "<xdgData>/<game>", "<xdgData>/Game"},
+		{"store user", linux, "<home>/<storeUserId>/saves", "<home>/*/saves"},
+		{"xdg on linux", linux, "<xdgConfig>/game", "<xdgConfig>/game"},
+		{"windows path on linux", linux, "<winAppData>/Game", ""},
+		{"native windows", windows, "<winAppData>/Game", "${APPDATA}/Game"},
+		{"xdg on windows", windows, "<xdgData>/game", ""},
+		{"not installed", windows, "<base>/saves", ""},
+		{"unknown placeholder", linux, "<nope>/saves", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.target.translate(tt.path)
+			if !ok {
+				got = ""
+			}
+			if got != tt.want {
+				t.Errorf("translate(%q) = %q, %v; want %q", tt.path, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommonDir(t *testing.T) {
+	tests := []struct {
+		dirs []string
+		want string
+	}{
+		{[]string{"/a/b/c"}, "/a/b/c"},
+		{[]string{"/a/b/c", "/a/b/d"}, "/a/b"},
+		{[]string{"/a/b", "/a/b/c/d"}, "/a/b"},
+		{[]string{"/a/bc", "/a/b"}, "/a"},
+		{[]string{"/a", "/b"}, "/"},
+	}
+	for _, tt := range tests {
+		dirs := make([]string, len(tt.dirs))
+		for i, dir := range tt.dirs {
+			dirs[i] = filepath.FromSlash(dir)
+		}
+		if got := commonDir(dirs); got != filepath.FromSlash(tt.want) {
+			t.Errorf("commonDir(%v) = %q, want %q", tt.dirs, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// Package paths expands the shorthand allowed in configured paths.
+//
+// Paths are stored in the configuration exactly as the user typed them, so
+// a config written on one machine keeps working on another. They are only
+// expanded when they are about to be used.
+package paths
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Options carries the values of placeholders that depend on the game.
+type Options struct {
+	// Prefix is the Wine or Proton prefix that <prefix> expands to. It may
+	// itself use any of the shorthand Expand understands except <prefix>.
+	Prefix string
+}
+
+// Expand resolves a configured path. In order, it replaces
+//
+//   - named placeholders: <home>, <xdgData>, <xdgConfig>, <steam> and <prefix>
+//   - a leading ~ with the user's home directory
+//   - $VAR and ${VAR} with environment variables
+//
+// An unknown placeholder or unset environment variable is an error rather
+// than being replaced with an empty string.
+func Expand(path string, opts Options) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+
+	expanded, err := expandPlaceholders(path, opts)
+	if err != nil {
+		return "", err
+	}
+
+	if expanded == "~" || strings.HasPrefix(expanded, "~/") || strings.HasPrefix(expanded, `~\`) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		expanded = home + expanded[1:]
+	}
+
+	var missing string
+	expanded = os.Expand(expanded, func(name string) string {
+		value, ok := os.LookupEnv(name)
+		if !ok && missing == "" {
+			missing = name
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("%s: environment variable %s is not set", path, missing)
+	}
+
+	return filepath.Clean(expanded), nil
+}
+
+// expandPlaceholders replaces every <name> in path.
+func expandPlaceholders(path string, opts Options) (string, error) {
+	var b strings.Builder
+	rest := path
+	for {
+		start := strings.IndexByte(rest, '<')
+		if start < 0 {
+			b.WriteString(rest)
+			return b.String(), nil
+		}
+		end := strings.IndexByte(rest[start:], '>')
+		if end < 0 {
+			return "", fmt.Errorf("%s: unterminated placeholder", path)
+		}
+		end += start
+
+		value, err := placeholder(rest[start+1:end], opts)
+		if err != nil {
+			return "", fmt.Errorf("%s: %v", path, err)
+		}
+		b.WriteString(rest[:start])
+		b.WriteString(value)
+		rest = rest[end+1:]
+	}
+}
+
+// placeholder returns the value of a single named placeholder.
+func placeholder(name string, opts Options) (string, error) {
+	switch name {
+	case "home":
+		return os.UserHomeDir()
+	case "xdgData":
+		return xdgDir("XDG_DATA_HOME", ".local/share", "APPDATA")
+	case "xdgConfig":
+		return xdgDir("XDG_CONFIG_HOME", ".config", "APPDATA")
+	case "steam":
+		return SteamRoot()
+	case "prefix":
+		if opts.Prefix == "" {
+			if prefix, ok := os.LookupEnv("WINEPREFIX"); ok {
+				return prefix, nil
+			}
+			return "", fmt.Errorf("<prefix> is used but the game has no prefix set")
+		}
+		if strings.Contains(opts.Prefix, "<prefix>") {
+			return "", fmt.Errorf("the game's prefix refers to itself")
+		}
+		return Expand(opts.Prefix, Options{})
+	}
+	return "", fmt.Errorf("unknown placeholder <%s>", name)
+}
+
+// xdgDir returns an XDG base directory: the environment variable if it is
+// set, otherwise its default below the home directory. On Windows the
+// closest equivalent folder is used instead.
+func xdgDir(env, fallback, windowsEnv string) (string, error) {
+	if dir := os.Getenv(env); dir != "" {
+		return dir, nil
+	}
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv(windowsEnv); dir != "" {
+			return dir, nil
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, filepath.FromSlash(fallback)), nil
+}
+
+// SteamRoot returns the Steam installation directory, checking the usual
+// locations for the current platform including the Flatpak package.
+func SteamRoot() (string, error) {
+	roots := SteamRoots()
+	if len(roots) == 0 {
+		return "", fmt.Errorf("Steam installation not found")
+	}
+	return roots[0], nil
+}
+
+// SteamRoots returns every Steam installation found, such as a native and a
+// Flatpak install side by side. Symlinked locations are only listed once.
+func SteamRoots() []string {
+	var roots []string
+	seen := make(map[string]bool)
+	for _, dir := range steamCandidates() {
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		real, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			real = dir
+		}
+		if seen[real] {
+			continue
+		}
+		seen[real] = true
+		roots = append(roots, dir)
+	}
+	return roots
+}
+
+// ContractHome rewrites a path inside the home directory to start with ~,
+// which keeps generated configs portable between machines.
+func ContractHome(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	rel, err := filepath.Rel(home, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	if rel == "." {
+		return "~"
+	}
+	return "~/" + filepath.ToSlash(rel)
+}
+
+// steamCandidates lists the directories Steam is commonly installed to.
+func steamCandidates() []string {
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "windows":
+		var dirs []string
+		for _, env := range []string{"ProgramFiles(x86)", "ProgramFiles"} {
+			if dir := os.Getenv(env); dir != "" {
+				dirs = append(dirs, filepath.Join(dir, "Steam"))
+			}
+		}
+		return dirs
+	case "darwin":
+		return []string{filepath.Join(home, "Library", "Application Support", "Steam")}
+	}
+	return []string{
+		filepath.Join(home, ".steam", "steam"),
+		filepath.Join(home, ".local", "share", "Steam"),
+		filepath.Join(home, ".var", "app", "com.valvesoftware.Steam", ".local", "share", "Steam"),
+	}
+}
@@ -0,0 +1,165 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// testHome points the home directory and XDG variables at a temporary
+// directory and returns it.
+func testHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	return home
+}
+
+func TestExpand(t *testing.T) {
+	home := testHome(t)
+	t.Setenv("GAME_DIR", "/games")
+	t.Setenv("SLOT", "slot1")
+	t.Setenv("WINEPREFIX", "/wine/default")
+
+	tests := []struct {
+		path string
+		opts Options
+		want string
+	}{
+		{"", Options{}, ""},
+		{"/saves/game", Options{}, "/saves/game"},
+		{"/saves//game/../game/", Options{}, "/saves/game"},
+		{"~", Options{}, home},
+		{"~/saves", Options{}, filepath.Join(home, "saves")},
+		{"/tmp/~/saves", Options{}, "/tmp/~/saves"},
+		{"~other/saves", Options{}, "~other/saves"},
+		{"$GAME_DIR/saves", Options{}, "/games/saves"},
+		{"${GAME_DIR}/saves/${SLOT}", Options{}, "/games/saves/slot1"},
+		{"<home>/saves", Options{}, filepath.Join(home, "saves")},
+		{"<xdgData>/game", Options{}, filepath.Join(home, ".local", "share", "game")},
+		{"<xdgConfig>/game", Options{}, filepath.Join(home, ".config", "game")},
+		{"<prefix>/drive_c", Options{Prefix: "/wine/game"}, "/wine/game/drive_c"},
+		{"<prefix>/drive_c", Options{Prefix: "~/wine"}, filepath.Join(home, "wine", "drive_c")},
+		{"<prefix>/drive_c", Options{Prefix: "$GAME_DIR/pfx"}, "/games/pfx/drive_c"},
+		{"<prefix>/drive_c", Options{}, "/wine/default/drive_c"},
+		// A placeholder can expand to something starting with ~
+		{"<prefix>/drive_c", Options{Prefix: "<home>/pfx"}, filepath.Join(home, "pfx", "drive_c")},
+	}
+	for _, tt := range tests {
+		got, err := Expand(tt.path, tt.opts)
+		if err != nil {
+			t.Errorf("Expand(%q, %+v) error = %v", tt.path, tt.opts, err)
+			continue
+		}
+		if want := filepath.FromSlash(tt.want); got != want {
+			t.Errorf("Expand(%q, %+v) = %q, want %q", tt.path, tt.opts, got, want)
+		}
+	}
+}
+
+func TestExpandXDGVariables(t *testing.T) {
+	testHome(t)
+	t.Setenv("XDG_DATA_HOME", "/data")
+	t.Setenv("XDG_CONFIG_HOME", "/config")
+
+	for path, want := range map[string]string{"<xdgData>/game": "/data/game", "<xdgConfig>/game": "/config/game"} {
+		got, err := Expand(path, Options{})
+		if err != nil {
+			t.Errorf("Expand(%q) error = %v", path, err)
+			continue
+		}
+		if want = filepath.FromSlash(want); got != want {
+			t.Errorf("Expand(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestExpandSteam(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Steam locations below the home directory are only checked on Linux")
+	}
+	home := testHome(t)
+	if _, err := Expand("<steam>/userdata", Options{}); err == nil {
+		t.Error("Expand(<steam>) succeeded without a Steam installation")
+	}
+
+	steam := filepath.Join(home, ".local", "share", "Steam")
+	if err := os.MkdirAll(steam, 0755); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Expand("<steam>/userdata", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(steam, "userdata"); got != want {
+		t.Errorf("Expand(<steam>/userdata) = %q, want %q", got, want)
+	}
+}
+
+func TestExpandErrors(t *testing.T) {
+	testHome(t)
+	t.Setenv("GSBM_TEST_EMPTY", "")
+
+	tests := []struct {
+		path string
+		opts Options
+		want string
+	}{
+		{"$GSBM_TEST_UNSET/saves", Options{}, "environment variable GSBM_TEST_UNSET is not set"},
+		{"/saves/${GSBM_TEST_UNSET}", Options{}, "environment variable GSBM_TEST_UNSET is not set"},
+		{"<nope>/saves", Options{}, "unknown placeholder <nope>"},
+		{"<home/saves", Options{}, "unterminated placeholder"},
+		{"<prefix>/drive_c", Options{Prefix: "<prefix>/x"}, "refers to itself"},
+		{"<prefix>/drive_c", Options{Prefix: "$GSBM_TEST_UNSET"}, "GSBM_TEST_UNSET is not set"},
+	}
+	for _, tt := range tests {
+		_, err := Expand(tt.path, tt.opts)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Expand(%q, %+v) error = %v, want one containing %q", tt.path, tt.opts, err, tt.want)
+		}
+	}
+
+	// An empty variable is set, so it is not an error
+	if got, err := Expand("/saves$GSBM_TEST_EMPTY", Options{}); err != nil || got != filepath.FromSlash("/saves") {
+		t.Errorf("Expand with an empty variable = %q, %v; want /saves", got, err)
+	}
+}
+
+func TestExpandPrefixWithoutWineprefix(t *testing.T) {
+	testHome(t)
+	if prefix, ok := os.LookupEnv("WINEPREFIX"); ok {
+		t.Cleanup(func() { os.Setenv("WINEPREFIX", prefix) })
+		os.Unsetenv("WINEPREFIX")
+	}
+	_, err := Expand("<prefix>/drive_c", Options{})
+	if err == nil || !strings.Contains(err.Error(), "no prefix set") {
+		t.Errorf("Expand(<prefix>) error = %v, want one saying the game has no prefix", err)
+	}
+}
+
+func TestContractHome(t *testing.T) {
+	home := testHome(t)
+	tests := []struct {
+		path string
+		want string
+	}{
+		{home, "~"},
+		{filepath.Join(home, "saves", "game"), "~/saves/game"},
+		{filepath.Join(filepath.Dir(home), "other"), filepath.Join(filepath.Dir(home), "other")},
+		{home + "-sibling", home + "-sibling"},
+	}
+	for _, tt := range tests {
+		if got := ContractHome(tt.path); got != tt.want {
+			t.Errorf("ContractHome(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+		// A contracted path expands back to the original
+		if got, err := Expand(ContractHome(tt.path), Options{}); err != nil || got != tt.path {
+			t.Errorf("Expand(ContractHome(%q)) = %q, %v", tt.path, got, err)
+		}
+	}
+}
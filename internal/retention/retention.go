@@ -0,0 +1,120 @@
+package retention
+
+import (
+	"sort"
+	"time"
+
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/backup"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/config"
+)
+
+// Decision records whether a backup survives pruning and why.
+type Decision struct {
+	Backup  backup.Backup
+	Keep    bool
+	Reasons []string // rules that selected the backup; empty if it is removed
+}
+
+// Plan applies policy to backups and returns a decision for each of them,
+// newest first. Within every day, week or month covered by a rule the newest
+// backup is kept. If the policy has no rules enabled, every backup is kept.
+func Plan(backups []backup.Backup, policy config.Retention, now time.Time) []Decision {
+	sorted := make([]backup.Backup, len(backups))
+	copy(sorted, backups)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+
+	decisions := make([]Decision, len(sorted))
+	for i, b := range sorted {
+		decisions[i] = Decision{Backup: b}
+	}
+
+	if policy.IsEmpty() {
+		for i := range decisions {
+			decisions[i].Keep = true
+		}
+		return decisions
+	}
+
+	keep := func(i int, reason string) {
+		decisions[i].Keep = true
+		decisions[i].Reasons = append(decisions[i].Reasons, reason)
+	}
+
+	for i := 0; i < len(decisions) && i < policy.KeepLast; i++ {
+		keep(i, "last")
+	}
+
+	now = now.Local()
+	rules := []struct {
+		reason string
+		count  int
+		start  func(t time.Time) time.Time
+		prev   func(t time.Time) time.Time
+	}{
+		{"daily", policy.KeepDaily, startOfDay, func(t time.Time) time.Time { return t.AddDate(0, 0, -1) }},
+		{"weekly", policy.KeepWeekly, startOfWeek, func(t time.Time) time.Time { return t.AddDate(0, 0, -7) }},
+		{"monthly", policy.KeepMonthly, startOfMonth, func(t time.Time) time.Time { return t.AddDate(0, -1, 0) }},
+	}
+
+	for _, rule := range rules {
+		if rule.count <= 0 {
+			continue
+		}
+
+		// The oldest period covered by the rule starts count-1 periods before
+		// the current one
+		oldest := rule.start(now)
+		for n := 1; n < rule.count; n++ {
+			oldest = rule.start(rule.prev(oldest))
+		}
+
+		// Backups are sorted newest first, so the first one seen in each
+		// period is the one to keep
+		seen := make(map[time.Time]bool)
+		for i, d := range decisions {
+			created := d.Backup.CreatedAt.Local()
+			if created.Before(oldest) {
+				break
+			}
+			period := rule.start(created)
+			if seen[period] {
+				continue
+			}
+			seen[period] = true
+			keep(i, rule.reason)
+		}
+	}
+
+	return decisions
+}
+
+// Removals returns the backups a plan would delete.
+func Removals(decisions []Decision) []backup.Backup {
+	var remove []backup.Backup
+	for _, d := range decisions {
+		if !d.Keep {
+			remove = append(remove, d.Backup)
+		}
+	}
+	return remove
+}
+
+// startOfDay returns midnight at the start of t's day.
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// startOfWeek returns midnight on the Monday of t's week.
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7 // days since Monday
+	return startOfDay(t).AddDate(0, 0, -offset)
+}
+
+// startOfMonth returns midnight on the first day of t's month.
+func startOfMonth(t time.Time) time.Time {
+	y, m, _ := t.Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,207 @@
+package retention
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/backup"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/config"
+)
+
+const testLayout = "2006-01-02 15:04"
+
+func at(t *testing.T, value string) time.Time {
+	t.Helper()
+	tm, err := time.ParseInLocation(testLayout, value, time.Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tm
+}
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		now     string
+		policy  config.Retention
+		backups []string
+		want    map[string][]string // kept backups and the rules that kept them
+	}{
+		{
+			name:    "no rules keeps everything",
+			now:     "2026-10-16 12:00",
+			backups: []string{"2026-10-16 09:00", "2025-01-01 00:00"},
+			want:    map[string][]string{"2026-10-16 09:00": nil, "2025-01-01 00:00": nil},
+		},
+		{
+			name:    "keep last",
+			now:     "2026-10-16 12:00",
+			policy:  config.Retention{KeepLast: 2},
+			backups: []string{"2026-10-16 09:00", "2026-10-16 08:00", "2026-10-16 07:00", "2026-10-01 07:00"},
+			want:    map[string][]string{"2026-10-16 09:00": {"last"}, "2026-10-16 08:00": {"last"}},
+		},
+		{
+			name:   "days start at midnight",
+			now:    "2026-10-16 12:00",
+			policy: config.Retention{KeepDaily: 3},
+			backups: []string{
+				"2026-10-16 09:00", "2026-10-16 00:01",
+				"2026-10-15 23:59", "2026-10-15 08:00",
+				"2026-10-14 00:00",
+				"2026-10-13 23:59", // the fourth day back
+			},
+			want: map[string][]string{
+				"2026-10-16 09:00": {"daily"},
+				"2026-10-15 23:59": {"daily"},
+				"2026-10-14 00:00": {"daily"},
+			},
+		},
+		{
+			name:   "weeks start on Monday",
+			now:    "2026-10-16 12:00", // a Friday
+			policy: config.Retention{KeepWeekly: 2},
+			backups: []string{
+				"2026-10-12 00:00", // Monday
+				"2026-10-11 23:59", // Sunday of the week before
+				"2026-10-05 00:00",
+				"2026-10-04 23:59", // the third week back
+			},
+			want: map[string][]string{
+				"2026-10-12 00:00": {"weekly"},
+				"2026-10-11 23:59": {"weekly"},
+			},
+		},
+		{
+			name:   "week across the new year",
+			now:    "2027-01-01 12:00", // a Friday in the week of Monday 2026-12-28
+			policy: config.Retention{KeepWeekly: 1},
+			backups: []string{
+				"2026-12-31 10:00",
+				"2026-12-28 00:00",
+				"2026-12-27 23:59",
+			},
+			want: map[string][]string{"2026-12-31 10:00": {"weekly"}},
+		},
+		{
+			name:   "months across the new year",
+			now:    "2027-01-10 12:00",
+			policy: config.Retention{KeepMonthly: 3},
+			backups: []string{
+				"2027-01-01 00:00",
+				"2026-12-31 23:59", "2026-12-01 00:00",
+				"2026-11-30 23:59",
+				"2026-10-31 23:59", // the fourth month back
+			},
+			want: map[string][]string{
+				"2027-01-01 00:00": {"monthly"},
+				"2026-12-31 23:59": {"monthly"},
+				"2026-11-30 23:59": {"monthly"},
+			},
+		},
+		{
+			name:   "month rule reaches back past short months",
+			now:    "2026-03-31 12:00",
+			policy: config.Retention{KeepMonthly: 2},
+			backups: []string{
+				"2026-03-01 00:00",
+				"2026-02-28 23:59",
+				"2026-01-31 23:59",
+			},
+			want: map[string][]string{
+				"2026-03-01 00:00": {"monthly"},
+				"2026-02-28 23:59": {"monthly"},
+			},
+		},
+		{
+			name:   "zero counts turn rules off",
+			now:    "2026-10-16 12:00",
+			policy: config.Retention{KeepLast: 0, KeepDaily: 0, KeepWeekly: 0, KeepMonthly: 1},
+			backups: []string{
+				"2026-10-16 09:00", "2026-10-15 09:00", "2026-09-30 09:00",
+			},
+			want: map[string][]string{"2026-10-16 09:00": {"monthly"}},
+		},
+		{
+			name:   "a backup can be kept by several rules",
+			now:    "2026-10-16 12:00",
+			policy: config.Retention{KeepLast: 1, KeepDaily: 2, KeepWeekly: 2, KeepMonthly: 2},
+			backups: []string{
+				"2026-10-16 09:00",
+				"2026-10-15 09:00",
+				"2026-10-09 09:00",
+				"2026-09-30 09:00",
+				"2026-09-29 09:00",
+			},
+			want: map[string][]string{
+				"2026-10-16 09:00": {"last", "daily", "weekly", "monthly"},
+				"2026-10-15 09:00": {"daily"},
+				"2026-10-09 09:00": {"weekly"},
+				"2026-09-30 09:00": {"monthly"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backups := make([]backup.Backup, len(tt.backups))
+			for i, created := range tt.backups {
+				backups[i] = backup.Backup{ID: i + 1, CreatedAt: at(t, created)}
+			}
+
+			decisions := Plan(backups, tt.policy, at(t, tt.now))
+			if len(decisions) != len(backups) {
+				t.Fatalf("got %d decisions for %d backups", len(decisions), len(backups))
+			}
+			for _, d := range decisions {
+				created := d.Backup.CreatedAt.Format(testLayout)
+				reasons, want := tt.want[created]
+				if d.Keep != want {
+					t.Errorf("%s: Keep = %v, want %v", created, d.Keep, want)
+				}
+				if !reflect.DeepEqual(d.Reasons, reasons) {
+					t.Errorf("%s: Reasons = %v, want %v", created, d.Reasons, reasons)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanSortsNewestFirst(t *testing.T) {
+	backups := []backup.Backup{
+		{ID: 1, CreatedAt: at(t, "2026-10-14 09:00")},
+		{ID: 2, CreatedAt: at(t, "2026-10-16 09:00")},
+		{ID: 3, CreatedAt: at(t, "2026-10-15 09:00")},
+	}
+	decisions := Plan(backups, config.Retention{KeepLast: 1}, at(t, "2026-10-16 12:00"))
+
+	var order []int
+	for _, d := range decisions {
+		order = append(order, d.Backup.ID)
+	}
+	if want := []int{2, 3, 1}; !reflect.DeepEqual(order, want) {
+		t.Errorf("decision order = %v, want %v", order, want)
+	}
+	if !decisions[0].Keep || decisions[1].Keep || decisions[2].Keep {
+		t.Errorf("only the newest backup should be kept: %+v", decisions)
+	}
+}
+
+func TestRemovals(t *testing.T) {
+	decisions := []Decision{
+		{Backup: backup.Backup{ID: 1}, Keep: true, Reasons: []string{"last"}},
+		{Backup: backup.Backup{ID: 2}},
+		{Backup: backup.Backup{ID: 3}, Keep: true, Reasons: []string{"daily"}},
+		{Backup: backup.Backup{ID: 4}},
+	}
+	var ids []int
+	for _, b := range Removals(decisions) {
+		ids = append(ids, b.ID)
+	}
+	if want := []int{2, 4}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Removals() = %v, want %v", ids, want)
+	}
+	if got := Removals(nil); got != nil {
+		t.Errorf("Removals(nil) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,358 @@
+// Package selector parses the small query language used to pick backups,
+// both on the command line and in the TUI's filter box.
+//
+// A selector is a list of terms separated by spaces. A backup is selected
+// if it matches every term:
+//
+//	latest            the newest backup that matches the other terms
+//	latest~N          the Nth backup before the newest
+//	id:42             backup 42; id:42,43 for either
+//	name:boss*        names matching a glob pattern, ignoring case
+//	tag:milestone     backups with the tag; tag:a,b for either
+//	before:DATE       created before DATE
+//	after:DATE        created on or after DATE
+//	game:eldenring    backups of another game than the active one
+//	42                the same as id:42
+//	boss              names, notes or tags containing the text
+//
+// DATE is 2006-01-02, 2006-01-02T15:04 or RFC 3339, in local time, or an
+// age such as 12h, 7d or 2w. A leading - negates a term, as in -tag:auto,
+// and values containing spaces can be quoted: name:"before boss".
+package selector
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/backup"
+)
+
+// Selector is a parsed query.
+type Selector struct {
+	query   string
+	filters []filter
+	game    string
+	latest  int // how far back from the newest match to go; -1 for all matches
+}
+
+// filter is one term that backups are tested against.
+type filter struct {
+	match  func(b backup.Backup) bool
+	negate bool
+}
+
+// Parse parses a query typed as one string, such as in the filter box.
+func Parse(query string) (*Selector, error) {
+	terms, err := split(query)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTerms(terms)
+}
+
+// ParseTerms parses a query that has already been split into terms, such as
+// command-line arguments.
+func ParseTerms(terms []string) (*Selector, error) {
+	return parseTerms(terms, time.Now())
+}
+
+func parseTerms(terms []string, now time.Time) (*Selector, error) {
+	s := &Selector{latest: -1}
+	var quoted []string
+	for _, term := range terms {
+		if term == "" {
+			continue
+		}
+		if err := s.add(term, now); err != nil {
+			return nil, err
+		}
+		quoted = append(quoted, quote(term))
+	}
+	s.query = strings.Join(quoted, " ")
+	return s, nil
+}
+
+// quote puts quotes around the value of a term containing spaces, so the
+// term reads back the same way.
+func quote(term string) string {
+	if !strings.ContainsFunc(term, unicode.IsSpace) {
+		return term
+	}
+	if key, value, ok := strings.Cut(term, ":"); ok {
+		return key + `:"` + value + `"`
+	}
+	return `"` + term + `"`
+}
+
+// split breaks a query into terms at spaces outside double quotes, and
+// removes the quotes.
+func split(query string) ([]string, error) {
+	var terms []string
+	var term strings.Builder
+	inTerm, quoted := false, false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inTerm = true
+		case unicode.IsSpace(r) && !quoted:
+			if inTerm {
+				terms = append(terms, term.String())
+				term.Reset()
+				inTerm = false
+			}
+		default:
+			term.WriteRune(r)
+			inTerm = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in %q", query)
+	}
+	if inTerm {
+		terms = append(terms, term.String())
+	}
+	return terms, nil
+}
+
+// add parses one term.
+func (s *Selector) add(term string, now time.Time) error {
+	if term == "latest" || strings.HasPrefix(term, "latest~") {
+		if s.latest >= 0 {
+			return fmt.Errorf("%s: only one latest is allowed", term)
+		}
+		s.latest = 0
+		if n := strings.TrimPrefix(term, "latest"); n != "" {
+			back, err := strconv.Atoi(n[1:])
+			if err != nil || back < 0 {
+				return fmt.Errorf("%s: expected latest~N with N a whole number", term)
+			}
+			s.latest = back
+		}
+		return nil
+	}
+
+	negate := strings.HasPrefix(term, "-") && len(term) > 1
+	body := term
+	if negate {
+		body = term[1:]
+	}
+
+	key, value, ok := strings.Cut(body, ":")
+	if !ok {
+		return s.addBare(body, negate)
+	}
+	if value == "" {
+		return fmt.Errorf("%s: missing value", term)
+	}
+
+	var match func(b backup.Backup) bool
+	switch key {
+	case "id":
+		ids, err := parseIDs(value)
+		if err != nil {
+			return fmt.Errorf("%s: %v", term, err)
+		}
+		match = matchID(ids)
+	case "name":
+		patterns, err := parsePatterns(value)
+		if err != nil {
+			return fmt.Errorf("%s: %v", term, err)
+		}
+		match = func(b backup.Backup) bool { return matchAny(patterns, b.Name) }
+	case "tag":
+		patterns, err := parsePatterns(value)
+		if err != nil {
+			return fmt.Errorf("%s: %v", term, err)
+		}
+		match = func(b backup.Backup) bool {
+			for _, tag := range b.Tags {
+				if matchAny(patterns, tag) {
+					return true
+				}
+			}
+			return false
+		}
+	case "before", "after":
+		t, err := parseTime(value, now)
+		if err != nil {
+			return fmt.Errorf("%s: %v", term, err)
+		}
+		if key == "before" {
+			match = func(b backup.Backup) bool { return b.CreatedAt.Before(t) }
+		} else {
+			match = func(b backup.Backup) bool { return !b.CreatedAt.Before(t) }
+		}
+	case "game":
+		if negate {
+			return fmt.Errorf("%s: game cannot be negated", term)
+		}
+		if s.game != "" && s.game != value {
+			return fmt.Errorf("%s: only one game is allowed", term)
+		}
+		s.game = value
+		return nil
+	default:
+		return fmt.Errorf("%s: unknown selector %q; expected id, name, tag, before, after or game", term, key+":")
+	}
+	s.filters = append(s.filters, filter{match: match, negate: negate})
+	return nil
+}
+
+// addBare adds a term without a key: a list of IDs, or text to search for.
+func (s *Selector) addBare(value string, negate bool) error {
+	if ids, err := parseIDs(value); err == nil {
+		s.filters = append(s.filters, filter{match: matchID(ids), negate: negate})
+		return nil
+	}
+	text := strings.ToLower(value)
+	s.filters = append(s.filters, filter{negate: negate, match: func(b backup.Backup) bool {
+		if strings.Contains(strings.ToLower(b.Name), text) || strings.Contains(strings.ToLower(b.Note), text) {
+			return true
+		}
+		for _, tag := range b.Tags {
+			if strings.Contains(strings.ToLower(tag), text) {
+				return true
+			}
+		}
+		return false
+	}})
+	return nil
+}
+
+func parseIDs(value string) ([]int, error) {
+	var ids []int
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a backup ID", part)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func matchID(ids []int) func(b backup.Backup) bool {
+	return func(b backup.Backup) bool {
+		for _, id := range ids {
+			if b.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// parsePatterns splits comma-separated glob patterns and lowercases them.
+func parsePatterns(value string) ([]string, error) {
+	var patterns []string
+	for _, p := range strings.Split(value, ",") {
+		p = strings.ToLower(p)
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("%q is not a valid pattern", p)
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+func matchAny(patterns []string, s string) bool {
+	s = strings.ToLower(s)
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, s); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTime reads a date, a date and time, or an age before now.
+func parseTime(value string, now time.Time) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "2006-01-02T15:04", "2006-01-02T15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	if len(value) > 1 {
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err == nil && n >= 0 {
+			switch value[len(value)-1] {
+			case 'h':
+				return now.Add(-time.Duration(n) * time.Hour), nil
+			case 'd':
+				return now.AddDate(0, 0, -n), nil
+			case 'w':
+				return now.AddDate(0, 0, -7*n), nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date (2006-01-02) or an age (12h, 7d, 2w)", value)
+}
+
+// String returns the query the selector was parsed from, with quotes
+// where they are needed.
+func (s *Selector) String() string {
+	return s.query
+}
+
+// Game returns the game named with game:, or "" to use the active game.
+func (s *Selector) Game() string {
+	return s.game
+}
+
+// Single reports whether the selector picks at most one backup with latest.
+func (s *Selector) Single() bool {
+	return s.latest >= 0
+}
+
+// Match reports whether a backup matches every term except latest and game.
+func (s *Selector) Match(b backup.Backup) bool {
+	for _, f := range s.filters {
+		if f.match(b) == f.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// Indexes returns the positions in backups of the selected backups, in the
+// order they appear.
+func (s *Selector) Indexes(backups []backup.Backup) []int {
+	var matched []int
+	for i, b := range backups {
+		if s.Match(b) {
+			matched = append(matched, i)
+		}
+	}
+	if s.latest < 0 {
+		return matched
+	}
+
+	newest := append([]int(nil), matched...)
+	sort.SliceStable(newest, func(i, j int) bool {
+		return backups[newest[i]].CreatedAt.After(backups[newest[j]].CreatedAt)
+	})
+	if s.latest >= len(newest) {
+		return nil
+	}
+	return newest[s.latest : s.latest+1]
+}
+
+// Select returns the selected backups, in the order they appear.
+func (s *Selector) Select(backups []backup.Backup) []backup.Backup {
+	indexes := s.Indexes(backups)
+	selected := make([]backup.Backup, len(indexes))
+	for i, index := range indexes {
+		selected[i] = backups[index]
+	}
+	return selected
+}
@@ -0,0 +1,195 @@
+package selector
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/backup"
+)
+
+var testNow = time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+
+// testBackups is listed newest first, as the database returns backups,
+// except for ID 1, which was imported with an older creation time.
+var testBackups = []backup.Backup{
+	{ID: 5, Name: "Boss fight", CreatedAt: testNow.Add(-time.Hour), Tags: []string{"pre-boss"}},
+	{ID: 4, Name: "Backup_x", CreatedAt: testNow.Add(-48 * time.Hour), Note: "auto"},
+	{ID: 3, Name: "before boss", CreatedAt: testNow.AddDate(0, 0, -20), Tags: []string{"milestone"}},
+	{ID: 1, Name: "imported", CreatedAt: testNow.AddDate(-1, 0, 0), Tags: []string{"auto"}},
+	{ID: 2, Name: "start", CreatedAt: testNow.AddDate(0, -2, 0)},
+}
+
+func parseAt(t *testing.T, query string) *Selector {
+	t.Helper()
+	terms, err := split(query)
+	if err != nil {
+		t.Fatalf("split(%q) error = %v", query, err)
+	}
+	s, err := parseTerms(terms, testNow)
+	if err != nil {
+		t.Fatalf("parseTerms(%q) error = %v", query, err)
+	}
+	return s
+}
+
+func selectedIDs(s *Selector, backups []backup.Backup) []int {
+	var ids []int
+	for _, b := range s.Select(backups) {
+		ids = append(ids, b.ID)
+	}
+	return ids
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"", []int{5, 4, 3, 1, 2}},
+		{"latest", []int{5}},
+		{"latest~0", []int{5}},
+		{"latest~1", []int{4}},
+		{"latest~4", []int{1}},
+		{"latest~5", nil},
+		{"id:3", []int{3}},
+		{"id:3,2", []int{3, 2}},
+		{"3,2", []int{3, 2}},
+		{"-id:3", []int{5, 4, 1, 2}},
+		{"name:boss*", []int{5}},
+		{"name:*BOSS*", []int{5, 3}},
+		{`name:"before boss"`, []int{3}},
+		{"-name:*boss*", []int{4, 1, 2}},
+		{"tag:milestone", []int{3}},
+		{"tag:pre-*,milestone", []int{5, 3}},
+		{"-tag:auto", []int{5, 4, 3, 2}},
+		{"boss", []int{5, 3}},
+		{"auto", []int{4, 1}},
+		{"-boss", []int{4, 1, 2}},
+		{"after:2d", []int{5, 4}},
+		{"after:47h", []int{5}},
+		{"before:2w", []int{3, 1, 2}},
+		{"before:2026-10-01", []int{3, 1, 2}},
+		{"after:2026-10-14T12:00", []int{5, 4}},
+		{"after:2026-09-01 before:1d", []int{4, 3}},
+		{"latest tag:milestone", []int{3}},
+		{"latest -tag:pre-boss", []int{4}},
+		{"latest~1 -tag:pre-boss", []int{3}},
+		{"game:other latest", []int{5}},
+		{"tag:nothing", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := selectedIDs(parseAt(t, tt.query), testBackups); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Select(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLatestUsesCreationTime(t *testing.T) {
+	// The list order is not the creation order, as after an import
+	backups := []backup.Backup{
+		{ID: 1, CreatedAt: testNow.Add(-2 * time.Hour)},
+		{ID: 2, CreatedAt: testNow},
+		{ID: 3, CreatedAt: testNow.Add(-time.Hour)},
+	}
+	for query, want := range map[string]int{"latest": 2, "latest~1": 3, "latest~2": 1} {
+		s := parseAt(t, query)
+		if got := s.Indexes(backups); len(got) != 1 || backups[got[0]].ID != want {
+			t.Errorf("Indexes(%q) = %v, want the index of backup %d", query, got, want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`name:"boss`, "unterminated quote"},
+		{"latest latest~1", "only one latest"},
+		{"latest~x", "expected latest~N"},
+		{"latest~-1", "expected latest~N"},
+		{"id:", "missing value"},
+		{"id:x", "not a backup ID"},
+		{"name:[", "not a valid pattern"},
+		{"before:yesterday", "is not a date"},
+		{"after:2026-13-01", "is not a date"},
+		{"after:-2d", "is not a date"},
+		{"-game:x", "cannot be negated"},
+		{"game:a game:b", "only one game"},
+		{"size:10", "unknown selector"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := Parse(tt.query)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse(%q) error = %v, want one containing %q", tt.query, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2026-10-01", time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local)},
+		{"2026-10-01T08:30", time.Date(2026, 10, 1, 8, 30, 0, 0, time.Local)},
+		{"2026-10-01T08:30:15", time.Date(2026, 10, 1, 8, 30, 15, 0, time.Local)},
+		{"2026-10-01T08:30:15Z", time.Date(2026, 10, 1, 8, 30, 15, 0, time.UTC)},
+		{"0h", testNow},
+		{"12h", testNow.Add(-12 * time.Hour)},
+		{"7d", testNow.AddDate(0, 0, -7)},
+		{"2w", testNow.AddDate(0, 0, -14)},
+	}
+	for _, tt := range tests {
+		got, err := parseTime(tt.value, testNow)
+		if err != nil {
+			t.Errorf("parseTime(%q) error = %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseTime(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseTermsKeepsSpaces(t *testing.T) {
+	// Command-line arguments arrive already split, spaces and all
+	s, err := ParseTerms([]string{"name:before boss", "", "-tag:auto"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := selectedIDs(s, testBackups); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("Select() = %v, want [3]", got)
+	}
+	if got, want := s.String(), `name:"before boss" -tag:auto`; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	// The string form parses back to the same selector
+	again, err := Parse(s.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != s.String() {
+		t.Errorf("Parse(%q).String() = %q", s.String(), again.String())
+	}
+}
+
+func TestGameAndSingle(t *testing.T) {
+	s := parseAt(t, "game:hades latest~2")
+	if s.Game() != "hades" {
+		t.Errorf("Game() = %q, want %q", s.Game(), "hades")
+	}
+	if !s.Single() {
+		t.Error("Single() = false for a selector with latest~2")
+	}
+	if s := parseAt(t, "tag:auto"); s.Single() || s.Game() != "" {
+		t.Errorf("tag:auto: Single() = %v, Game() = %q; want false and \"\"", s.Single(), s.Game())
+	}
+}
@@ -2,11 +2,16 @@ package services
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/backup"
 	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/components"
 	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/config"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/retention"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/selector"
 )
 
 // BackupService handles all backup-related business logic
@@ -23,28 +28,485 @@ func NewBackupService(db *backup.DB, config *config.Config) *BackupService {
 	}
 }
 
-// CreateBackup creates a new backup with the given name
-func (bs *BackupService) CreateBackup(name string) error {
-	return bs.db.CreateBackup(bs.config.SavePath, bs.config.BackupDir, name)
+// activeGame returns the game profile all operations are scoped to
+func (bs *BackupService) activeGame() (*config.Game, error) {
+	game := bs.config.ActiveGameProfile()
+	if game == nil {
+		return nil, fmt.Errorf("no game configured")
+	}
+	return game, nil
+}
+
+// checkActiveGame returns an error unless every backup belongs to the active
+// game, so a stale list or a selector can't act on another game's backups
+func (bs *BackupService) checkActiveGame(backups ...backup.Backup) error {
+	game, err := bs.activeGame()
+	if err != nil {
+		return err
+	}
+	for _, b := range backups {
+		if b.GameID != game.ID {
+			return fmt.Errorf("backup %q belongs to another game", b.Name)
+		}
+	}
+	return nil
+}
+
+// CreateBackup creates a new backup of the active game with the given name
+// and returns it
+func (bs *BackupService) CreateBackup(name string) (backup.Backup, error) {
+	game, err := bs.activeGame()
+	if err != nil {
+		return backup.Backup{}, err
+	}
+	savePath, err := game.ResolveSavePath()
+	if err != nil {
+		return backup.Backup{}, err
+	}
+	return bs.db.CreateBackup(savePath, name, bs.createOptions(game))
+}
+
+// createOptions builds the storage options for new backups of a game from the configuration
+func (bs *BackupService) createOptions(game *config.Game) backup.CreateOptions {
+	codec, level := bs.config.CompressionFor(game)
+	return backup.CreateOptions{
+		Compression: backup.Compression{
+			Codec: codec,
+			Level: level,
+		},
+		Filter: filterFor(game),
+		GameID: game.ID,
+	}
+}
+
+// filterFor returns the include/exclude filter configured for a game
+func filterFor(game *config.Game) backup.Filter {
+	return backup.Filter{Include: game.Include, Exclude: game.Exclude}
+}
+
+// PreviewFilter lists which files of the active game's save would be backed up
+func (bs *BackupService) PreviewFilter() (*backup.FilterPreview, error) {
+	game, err := bs.activeGame()
+	if err != nil {
+		return nil, err
+	}
+	filter := filterFor(game)
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	savePath, err := game.ResolveSavePath()
+	if err != nil {
+		return nil, err
+	}
+	return backup.PreviewFilter(savePath, filter)
 }
 
-// RestoreBackup restores the specified backup
-func (bs *BackupService) RestoreBackup(backup backup.Backup) error {
-	return bs.db.RestoreBackup(backup, bs.config.SavePath)
+// IsFirstDirectoryBackup returns true if the active game saves to a directory
+// and has no backups yet, so the files that will be included have not been seen
+func (bs *BackupService) IsFirstDirectoryBackup() (bool, error) {
+	if bs.db == nil {
+		return false, fmt.Errorf("database not initialized")
+	}
+	game, err := bs.activeGame()
+	if err != nil {
+		return false, err
+	}
+	savePath, err := game.ResolveSavePath()
+	if err != nil {
+		return false, err
+	}
+	if info, err := os.Stat(savePath); err != nil || !info.IsDir() {
+		return false, nil
+	}
+
+	backups, err := bs.db.GetBackups(game.ID)
+	if err != nil {
+		return false, err
+	}
+	return len(backups) == 0, nil
+}
+
+// RestoreBackup restores the specified backup over the active game's save
+func (bs *BackupService) RestoreBackup(b backup.Backup) error {
+	if err := bs.checkActiveGame(b); err != nil {
+		return err
+	}
+	savePath, err := bs.config.ActiveGameProfile().ResolveSavePath()
+	if err != nil {
+		return err
+	}
+	return bs.db.RestoreBackup(b, savePath)
+}
+
+// RestoreBackupWithAutoBackup restores a backup, first backing up the current
+// save if the active game has auto-backup enabled
+func (bs *BackupService) RestoreBackupWithAutoBackup(b backup.Backup) error {
+	if game, err := bs.activeGame(); err == nil && game.AutoBackup {
+		autoBackupName := fmt.Sprintf("Backup_%s", time.Now().Format("2006-01-02_15-04-05"))
+		if _, err := bs.CreateBackup(autoBackupName); err != nil {
+			return fmt.Errorf("failed to create auto-backup: %v", err)
+		}
+	}
+	return bs.RestoreBackup(b)
 }
 
-// DeleteBackups deletes multiple backups
+// DeleteBackups moves multiple backups to the trash
 func (bs *BackupService) DeleteBackups(backups []backup.Backup) error {
+	if err := bs.checkActiveGame(backups...); err != nil {
+		return err
+	}
 	return bs.db.DeleteBackups(backups)
 }
 
-// GetBackupItems fetches all backups and converts them to list items
-func (bs *BackupService) GetBackupItems() ([]list.Item, error) {
+// GetTrashItems fetches the backups in the trash and converts them to list items
+func (bs *BackupService) GetTrashItems() ([]list.Item, error) {
 	if bs.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
-	
-	backups, err := bs.db.GetBackups()
+
+	game, err := bs.activeGame()
+	if err != nil {
+		return nil, err
+	}
+
+	backups, err := bs.db.GetTrashedBackups(game.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	days := bs.config.TrashRetentionDays()
+	items := make([]list.Item, len(backups))
+	for i, b := range backups {
+		items[i] = components.TrashItem{Backup: b, PurgeAt: b.DeletedAt.AddDate(0, 0, days)}
+	}
+	return items, nil
+}
+
+// RestoreFromTrash takes backups out of the trash
+func (bs *BackupService) RestoreFromTrash(backups []backup.Backup) error {
+	if err := bs.checkActiveGame(backups...); err != nil {
+		return err
+	}
+	return bs.db.RestoreFromTrash(backups)
+}
+
+// PurgeBackups permanently deletes backups from the trash
+func (bs *BackupService) PurgeBackups(backups []backup.Backup) error {
+	if err := bs.checkActiveGame(backups...); err != nil {
+		return err
+	}
+	return bs.db.PurgeBackups(backups)
+}
+
+// PurgeExpiredTrash permanently deletes backups that have outlived the trash retention period
+func (bs *BackupService) PurgeExpiredTrash() (int, error) {
+	if bs.db == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+	days := bs.config.TrashRetentionDays()
+	return bs.db.PurgeExpired(time.Duration(days) * 24 * time.Hour)
+}
+
+// VerifyBackups rehashes every backup of the active game and reports its integrity
+func (bs *BackupService) VerifyBackups() ([]backup.VerifyResult, error) {
+	if bs.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	game, err := bs.activeGame()
+	if err != nil {
+		return nil, err
+	}
+
+	backups, err := bs.db.GetBackups(game.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]backup.VerifyResult, len(backups))
+	for i, b := range backups {
+		results[i] = bs.db.VerifyBackup(b)
+	}
+	return results, nil
+}
+
+// PlanPrune applies the active game's retention policy and returns what would be kept and removed
+func (bs *BackupService) PlanPrune() ([]retention.Decision, error) {
+	if bs.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	game, err := bs.activeGame()
+	if err != nil {
+		return nil, err
+	}
+	policy := bs.config.RetentionFor(game)
+	if policy.IsEmpty() {
+		return nil, fmt.Errorf("no retention rules configured")
+	}
+
+	backups, err := bs.db.GetBackups(game.ID)
+	if err != nil {
+		return nil, err
+	}
+	return retention.Plan(backups, policy, time.Now()), nil
+}
+
+// Prune deletes the backups a retention plan marked for removal
+func (bs *BackupService) Prune(decisions []retention.Decision) (int, error) {
+	remove := retention.Removals(decisions)
+	if len(remove) == 0 {
+		return 0, nil
+	}
+	if err := bs.DeleteBackups(remove); err != nil {
+		return 0, err
+	}
+	return len(remove), nil
+}
+
+// Reconcile compares the active game's backups with the disk. Stray .sav
+// files in the backup directory, left by versions from before game profiles,
+// are looked for only for the first game, which those backups belong to
+func (bs *BackupService) Reconcile() (*backup.ReconcileReport, error) {
+	if bs.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	game, err := bs.activeGame()
+	if err != nil {
+		return nil, err
+	}
+	strays := ""
+	if bs.config.OwnsLegacyBackups(game) {
+		if strays, err = bs.config.ResolveBackupDir(); err != nil {
+			return nil, err
+		}
+	}
+	return bs.db.Reconcile(game.ID, strays)
+}
+
+// AdoptOrphans records stray backup files found by Reconcile as backups of the active game
+func (bs *BackupService) AdoptOrphans(orphans []backup.OrphanFile) error {
+	game, err := bs.activeGame()
+	if err != nil {
+		return err
+	}
+	return bs.db.AdoptOrphans(game.ID, orphans)
+}
+
+// DropMissing removes database rows whose backup data no longer exists.
+// There is nothing left to keep, so they skip the trash.
+func (bs *BackupService) DropMissing(missing []backup.Backup) error {
+	if err := bs.checkActiveGame(missing...); err != nil {
+		return err
+	}
+	return bs.db.PurgeBackups(missing)
+}
+
+// RemoveUnusedObjects deletes objects in the store that no backup references
+func (bs *BackupService) RemoveUnusedObjects(ids []string) error {
+	return bs.db.RemoveObjects(ids)
+}
+
+// SetNote updates the note attached to a backup
+func (bs *BackupService) SetNote(b backup.Backup, note string) error {
+	if bs.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	if err := bs.checkActiveGame(b); err != nil {
+		return err
+	}
+	return bs.db.SetNote(b.ID, note)
+}
+
+// SetTags replaces the tags attached to a backup
+func (bs *BackupService) SetTags(b backup.Backup, tags []string) error {
+	if bs.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	if err := bs.checkActiveGame(b); err != nil {
+		return err
+	}
+	return bs.db.SetTags(b.ID, tags)
+}
+
+// GetBackups fetches the active game's backups, newest first
+func (bs *BackupService) GetBackups() ([]backup.Backup, error) {
+	if bs.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	game, err := bs.activeGame()
+	if err != nil {
+		return nil, err
+	}
+	return bs.db.GetBackups(game.ID)
+}
+
+// SelectBackups resolves a selector to concrete backups, newest first. The
+// active game's backups are searched unless the selector names another game.
+func (bs *BackupService) SelectBackups(sel *selector.Selector) ([]backup.Backup, error) {
+	if bs.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	game, err := bs.selectorGame(sel)
+	if err != nil {
+		return nil, err
+	}
+	backups, err := bs.db.GetBackups(game.ID)
+	if err != nil {
+		return nil, err
+	}
+	return sel.Select(backups), nil
+}
+
+// FilterBackups returns the positions in backups of those a selector query
+// picks, for filtering a list that is already loaded
+func (bs *BackupService) FilterBackups(query string, backups []backup.Backup) ([]int, error) {
+	sel, err := selector.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	if sel.Game() == "" {
+		return sel.Indexes(backups), nil
+	}
+
+	game, err := bs.selectorGame(sel)
+	if err != nil {
+		return nil, err
+	}
+	var positions []int
+	var ofGame []backup.Backup
+	for i, b := range backups {
+		if b.GameID == game.ID {
+			positions = append(positions, i)
+			ofGame = append(ofGame, b)
+		}
+	}
+	indexes := sel.Indexes(ofGame)
+	for i, index := range indexes {
+		indexes[i] = positions[index]
+	}
+	return indexes, nil
+}
+
+// selectorGame returns the game whose backups a selector searches
+func (bs *BackupService) selectorGame(sel *selector.Selector) (*config.Game, error) {
+	if sel.Game() == "" {
+		return bs.activeGame()
+	}
+	game := bs.config.LookupGame(sel.Game())
+	if game == nil {
+		return nil, fmt.Errorf("no game matches %q", sel.Game())
+	}
+	return game, nil
+}
+
+// ExportBackups writes backups to a new bundle file at path
+func (bs *BackupService) ExportBackups(path string, backups []backup.Backup) error {
+	if bs.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	if len(backups) == 0 {
+		return fmt.Errorf("no backups to export")
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+
+	names := make(map[string]string)
+	for _, g := range bs.config.Games {
+		names[g.ID] = g.Name
+	}
+
+	// Write to a temporary file first so a failed export leaves nothing behind
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".export-*.tar")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := bs.db.ExportBundle(tmp, backups, names); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ImportBundle adds the backups in the bundle file at path. Each backup goes
+// to the game with the same ID or name; backups of a game that matches none
+// are left out. With gameRef set, all of them go to that game instead.
+func (bs *BackupService) ImportBundle(path, onCollision, gameRef string) ([]backup.ImportResult, error) {
+	var target *config.Game
+	if gameRef != "" {
+		if target = bs.config.LookupGame(gameRef); target == nil {
+			return nil, fmt.Errorf("no game matches %q", gameRef)
+		}
+	}
+	return bs.importBundle(path, onCollision, func(g backup.BundleGame) *config.Game {
+		if target != nil {
+			return target
+		}
+		return bs.matchGame(g)
+	})
+}
+
+// ImportUnmatched adds the backups in the bundle file at path whose game
+// matches none of the configured games to the game gameRef, leaving out the
+// rest.
+func (bs *BackupService) ImportUnmatched(path, onCollision, gameRef string) ([]backup.ImportResult, error) {
+	target := bs.config.LookupGame(gameRef)
+	if target == nil {
+		return nil, fmt.Errorf("no game matches %q", gameRef)
+	}
+	return bs.importBundle(path, onCollision, func(g backup.BundleGame) *config.Game {
+		if bs.matchGame(g) != nil {
+			return nil
+		}
+		return target
+	})
+}
+
+// matchGame returns the game a bundled game's backups belong to: the one
+// with the same ID, or else the same name. It returns nil if there is none.
+func (bs *BackupService) matchGame(g backup.BundleGame) *config.Game {
+	if game := bs.config.LookupGame(g.ID); game != nil {
+		return game
+	}
+	if g.Name != "" {
+		return bs.config.LookupGame(g.Name)
+	}
+	return nil
+}
+
+// importBundle adds the backups in the bundle file at path to the games
+// gameFor picks, leaving out those it returns nil for.
+func (bs *BackupService) importBundle(path, onCollision string, gameFor func(backup.BundleGame) *config.Game) ([]backup.ImportResult, error) {
+	if bs.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return bs.db.ImportBundle(f, backup.ImportOptions{
+		GameFor: func(g backup.BundleGame) string {
+			if game := gameFor(g); game != nil {
+				return game.ID
+			}
+			return ""
+		},
+		Compression: func(gameID string) backup.Compression {
+			codec, level := bs.config.CompressionFor(bs.config.FindGame(gameID))
+			return backup.Compression{Codec: codec, Level: level}
+		},
+		OnCollision: onCollision,
+	})
+}
+
+// GetBackupItems fetches the active game's backups and converts them to list items
+func (bs *BackupService) GetBackupItems() ([]list.Item, error) {
+	backups, err := bs.GetBackups()
 	if err != nil {
 		return nil, err
 	}
@@ -56,13 +518,13 @@ func (bs *BackupService) GetBackupItems() ([]list.Item, error) {
 	return items, nil
 }
 
-// GetSelectedBackups converts selected indices to backup objects
+// GetSelectedBackups returns the backups among items whose IDs are selected
 func (bs *BackupService) GetSelectedBackups(items []list.Item, selected map[int]struct{}) []backup.Backup {
 	var backups []backup.Backup
-	for i, item := range items {
-		if _, ok := selected[i]; ok {
-			if listItem, ok := item.(components.ListItem); ok {
-				backups = append(backups, backup.Backup(listItem))
+	for _, item := range items {
+		if b, ok := components.BackupOf(item); ok {
+			if _, ok := selected[b.ID]; ok {
+				backups = append(backups, b)
 			}
 		}
 	}
@@ -75,11 +537,31 @@ func (bs *BackupService) InitializeDatabase() error {
 		return fmt.Errorf("configuration is missing or invalid")
 	}
 
-	db, err := backup.InitDB(bs.config.BackupDir)
+	backupDir, err := bs.config.ResolveBackupDir()
 	if err != nil {
 		return err
 	}
-	
+
+	// Backups made before game profiles existed belong to the first game
+	legacyGameID := ""
+	if len(bs.config.Games) > 0 {
+		legacyGameID = bs.config.Games[0].ID
+	}
+	db, err := backup.InitDB(backupDir, legacyGameID)
+	if err != nil {
+		return err
+	}
+
 	bs.db = db
 	return nil
-}
\ No newline at end of file
+}
+
+// Close closes the backup database
+func (bs *BackupService) Close() error {
+	if bs.db == nil {
+		return nil
+	}
+	err := bs.db.Close()
+	bs.db = nil
+	return err
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/backup"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/config"
+)
+
+// testService returns a service for two games, alpha and beta, with a backup
+// of alpha's save. alpha is active.
+func testService(t *testing.T) (*BackupService, backup.Backup) {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := &config.Config{BackupDir: filepath.Join(dir, "backups")}
+	for _, name := range []string{"alpha", "beta"} {
+		save := filepath.Join(dir, name+".sav")
+		if err := os.WriteFile(save, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cfg.AddGame(name, save)
+	}
+
+	bs := NewBackupService(nil, cfg)
+	if err := bs.InitializeDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { bs.Close() })
+	created, err := bs.CreateBackup("first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bs, created
+}
+
+func TestMutationsAreScopedToTheActiveGame(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(bs *BackupService, b backup.Backup) error
+	}{
+		{"restore", func(bs *BackupService, b backup.Backup) error { return bs.RestoreBackup(b) }},
+		{"delete", func(bs *BackupService, b backup.Backup) error { return bs.DeleteBackups([]backup.Backup{b}) }},
+		{"restore from trash", func(bs *BackupService, b backup.Backup) error { return bs.RestoreFromTrash([]backup.Backup{b}) }},
+		{"purge", func(bs *BackupService, b backup.Backup) error { return bs.PurgeBackups([]backup.Backup{b}) }},
+		{"drop missing", func(bs *BackupService, b backup.Backup) error { return bs.DropMissing([]backup.Backup{b}) }},
+		{"set note", func(bs *BackupService, b backup.Backup) error { return bs.SetNote(b, "note") }},
+		{"set tags", func(bs *BackupService, b backup.Backup) error { return bs.SetTags(b, []string{"tag"}) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs, alpha := testService(t)
+			bs.config.SetActiveGame("beta")
+
+			err := tt.run(bs, alpha)
+			if err == nil || !strings.Contains(err.Error(), "belongs to another game") {
+				t.Fatalf("error = %v, want one saying the backup belongs to another game", err)
+			}
+			backups, err := bs.db.GetBackups("alpha")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(backups) != 1 || backups[0].Note != "" || len(backups[0].Tags) != 0 {
+				t.Errorf("alpha's backups = %+v, want the backup untouched", backups)
+			}
+
+			// The same call works once the backup's game is active
+			bs.config.SetActiveGame("alpha")
+			if err := tt.run(bs, alpha); err != nil {
+				t.Errorf("error = %v with the backup's game active", err)
+			}
+		})
+	}
+}
+
+func TestReconcileFindsLegacyFilesForTheFirstGame(t *testing.T) {
+	bs, _ := testService(t)
+	backupDir, err := bs.config.ResolveBackupDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stray := filepath.Join(backupDir, "old.sav")
+	if err := os.WriteFile(stray, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for game, want := range map[string]int{"alpha": 1, "beta": 0} {
+		bs.config.SetActiveGame(game)
+		report, err := bs.Reconcile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Orphans) != want {
+			t.Errorf("%s: found stray files %+v, want %d", game, report.Orphans, want)
+		}
+	}
+}
@@ -17,6 +17,21 @@ const (
 	ChangeBackupDirView
 	FirstRunView
 	FirstRunBackupDirView
+	VerifyView
+	PruneView
+	EditNoteView
+	EditTagsView
+	ReconcileView
+	TrashView
+	GamePickerView
+	FilterPreviewView
+	FirstRunDiscoverView
+	ImportManifestView
+	FixConfigView
+	FixConfigFieldView
+	ExportBundleView
+	ImportBundleView
+	HealthView
 )
 
 // StateManager handles view state transitions and validation
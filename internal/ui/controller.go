@@ -5,9 +5,11 @@ import (
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/app"
 	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/components"
 	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/config"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/paths"
 	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/state"
 	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/views"
 )
@@ -17,7 +19,16 @@ type Controller struct {
 	app *app.Application
 	
 	// View handlers
-	mainMenuHandler *views.MainMenuHandler
+	mainMenuHandler   *views.MainMenuHandler
+	verifyHandler     *views.VerifyHandler
+	pruneHandler      *views.PruneHandler
+	reconcileHandler  *views.ReconcileHandler
+	trashHandler      *views.TrashHandler
+	gamePickerHandler *views.GamePickerHandler
+	previewHandler    *views.FilterPreviewHandler
+	discoverHandler   *views.DiscoverHandler
+	fixConfigHandler  *views.FixConfigHandler
+	healthHandler     *views.HealthHandler
 }
 
 // NewController creates a new UI controller
@@ -30,6 +41,20 @@ func NewController(cfg *config.Config, isFirstRun bool) *Controller {
 	
 	// Initialize view handlers
 	controller.mainMenuHandler = views.NewMainMenuHandler(application)
+	controller.verifyHandler = views.NewVerifyHandler(application)
+	controller.pruneHandler = views.NewPruneHandler(application)
+	controller.reconcileHandler = views.NewReconcileHandler(application)
+	controller.trashHandler = views.NewTrashHandler(application)
+	controller.gamePickerHandler = views.NewGamePickerHandler(application)
+	controller.previewHandler = views.NewFilterPreviewHandler(application)
+	controller.discoverHandler = views.NewDiscoverHandler(application)
+	controller.healthHandler = views.NewHealthHandler(application)
+	controller.fixConfigHandler = views.NewFixConfigHandler(application)
+	
+	// List the problems found in the configuration, if there are any
+	if len(application.GetConfigProblems()) > 0 {
+		application.ShowConfigProblems()
+	}
 	
 	return controller
 }
@@ -59,6 +84,33 @@ func (c *Controller) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case state.MainMenuView:
 		cmd := c.mainMenuHandler.Update(msg)
 		return c, cmd
+	case state.VerifyView:
+		cmd := c.verifyHandler.Update(msg)
+		return c, cmd
+	case state.PruneView:
+		cmd := c.pruneHandler.Update(msg)
+		return c, cmd
+	case state.ReconcileView:
+		cmd := c.reconcileHandler.Update(msg)
+		return c, cmd
+	case state.TrashView:
+		cmd := c.trashHandler.Update(msg)
+		return c, cmd
+	case state.GamePickerView:
+		cmd := c.gamePickerHandler.Update(msg)
+		return c, cmd
+	case state.FilterPreviewView:
+		cmd := c.previewHandler.Update(msg)
+		return c, cmd
+	case state.FirstRunDiscoverView:
+		cmd := c.discoverHandler.Update(msg)
+		return c, cmd
+	case state.FixConfigView:
+		cmd := c.fixConfigHandler.Update(msg)
+		return c, cmd
+	case state.HealthView:
+		cmd := c.healthHandler.Update(msg)
+		return c, cmd
 	case state.InitializingView:
 		// No updates while initializing
 		return c, nil
@@ -98,8 +150,15 @@ func (c *Controller) handleGlobalMessages(msg tea.Msg) tea.Cmd {
 		if msg.String() == "ctrl+c" {
 			return tea.Quit
 		}
+		// Cycle through games from any view that isn't taking text input
+		if msg.String() == "tab" && c.canSwitchGame() {
+			return c.cycleGame()
+		}
 		// Per-view key bindings for returning to main menu
 		if c.shouldAllowQuitToMainMenu() && msg.String() == "q" {
+			if c.app.IsInAnyState(state.BackupListView, state.ViewBackupsView) {
+				c.app.RememberListPosition()
+			}
 			c.app.TransitionToState(state.MainMenuView)
 			c.app.ClearNotification() // Clear any pending messages
 			return nil
@@ -109,7 +168,37 @@ func (c *Controller) handleGlobalMessages(msg tea.Msg) tea.Cmd {
 		c.app.ClearNotification()
 		return nil
 		
+	case app.DiscoveryCompletedMsg:
+		c.app.SetCandidates(msg.Candidates)
+		return nil
+		
+	case app.HealthCheckedMsg:
+		c.app.SetHealthReport(msg.Report)
+		return nil
+		
+	case app.ManifestResolvedMsg:
+		c.app.TransitionToState(state.SettingsView)
+		if msg.Err != nil {
+			c.app.FinishImportManifest()
+			return c.app.ShowNotification("Cannot import manifest: " + msg.Err.Error())
+		}
+		added, err := c.app.AddManifestProfiles(msg.Path, msg.Profiles)
+		if err != nil {
+			c.app.SetError(fmt.Errorf("failed to save imported games: %v", err))
+			return nil
+		}
+		if len(added) == 0 {
+			return c.app.ShowNotification("No new games with saves on this computer were found in the manifest")
+		}
+		return c.app.ShowNotification(fmt.Sprintf("Added %d game(s): %s", len(added), strings.Join(added, ", ")))
+		
 	case app.DatabaseInitializedMsg:
+		// Startup reconciliation found drift; let the user deal with it first
+		if msg.Reconcile != nil && !msg.Reconcile.Clean() {
+			c.app.SetReconcileReport(msg.Reconcile)
+			c.app.TransitionToState(state.ReconcileView)
+			return nil
+		}
 		c.app.TransitionToState(state.MainMenuView)
 		return nil
 		
@@ -124,7 +213,84 @@ func (c *Controller) handleGlobalMessages(msg tea.Msg) tea.Cmd {
 // shouldAllowQuitToMainMenu determines if 'q' should return to main menu
 func (c *Controller) shouldAllowQuitToMainMenu() bool {
 	currentState := c.app.GetCurrentState()
-	return currentState != state.FirstRunView && currentState != state.MainMenuView
+	// 'q' is regular input while typing text or a list filter
+	if c.isTextInputView(currentState) || c.app.GetList().SettingFilter() {
+		return false
+	}
+	return currentState != state.FirstRunView &&
+		currentState != state.FirstRunDiscoverView &&
+		currentState != state.FixConfigView &&
+		currentState != state.MainMenuView
+}
+
+// canSwitchGame determines if 'tab' should switch to the next game
+func (c *Controller) canSwitchGame() bool {
+	currentState := c.app.GetCurrentState()
+	if c.isTextInputView(currentState) || c.app.GetList().SettingFilter() {
+		return false
+	}
+	if c.app.IsInAnyState(state.InitializingView, state.GamePickerView, state.FixConfigView) || c.app.IsVerifying() || c.app.IsCheckingHealth() {
+		return false
+	}
+	return len(c.app.GetGames()) > 1
+}
+
+// cycleGame switches to the next game and reloads the current view for it.
+// Lists of backups keep each game's cursor and filter; views showing results
+// for the previous game go back to the main menu.
+func (c *Controller) cycleGame() tea.Cmd {
+	currentState := c.app.GetCurrentState()
+	isBackupList := c.isListView(currentState) || currentState == state.TrashView
+	// Selections in the delete and trash views are by position, so only the
+	// plain backup lists keep a filter
+	remember := c.app.IsInAnyState(state.BackupListView, state.ViewBackupsView)
+	if remember {
+		c.app.RememberListPosition()
+	}
+
+	if err := c.app.CycleGame(); err != nil {
+		c.app.SetError(fmt.Errorf("failed to switch game: %v", err))
+		return nil
+	}
+
+	var cmd tea.Cmd
+	switch {
+	case currentState == state.TrashView:
+		c.app.SetConfirmingPurge(false)
+		c.app.SetListDelegate(components.NewSelectableItemDelegate(c.app.GetSelections()))
+		cmd = c.app.RefreshTrashList()
+	case isBackupList:
+		if currentState == state.DeletingView {
+			c.app.SetListDelegate(components.NewSelectableItemDelegate(c.app.GetSelections()))
+		}
+		cmd = c.app.RefreshBackupList(c.app.GetList().Title)
+	case currentState != state.MainMenuView && currentState != state.SettingsView:
+		c.app.TransitionToState(state.MainMenuView)
+	}
+	if remember {
+		c.app.RestoreListPosition()
+	} else if isBackupList {
+		c.app.ResetListSelection()
+	}
+
+	notificationCmd := c.app.ShowNotification("Switched to " + c.app.ActiveGame().Name)
+	if cmd != nil {
+		return tea.Batch(cmd, notificationCmd)
+	}
+	return notificationCmd
+}
+
+// renderHeader renders the application title and the active game
+func (c *Controller) renderHeader() string {
+	styles := c.app.GetStyles()
+	title := styles.Title.Render("Game Save Backup Manager")
+
+	game := c.app.ActiveGame()
+	if game == nil || c.app.IsInAnyState(state.FirstRunView, state.FirstRunBackupDirView, state.InitializingView,
+		state.FixConfigView, state.FixConfigFieldView) {
+		return title
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Center, title, styles.Subtitle.Render("  ·  "+game.Name))
 }
 
 // currentView returns the string for the current view
@@ -134,6 +300,8 @@ func (c *Controller) currentView() string {
 	switch c.app.GetCurrentState() {
 	case state.InitializingView:
 		body.WriteString("Initializing...")
+	case state.FirstRunDiscoverView:
+		body.WriteString(c.discoverHandler.View())
 	case state.FirstRunView:
 		body.WriteString(c.renderFirstRunView())
 	case state.FirstRunBackupDirView:
@@ -156,6 +324,34 @@ func (c *Controller) currentView() string {
 		body.WriteString(c.renderChangeSavePathView())
 	case state.ChangeBackupDirView:
 		body.WriteString(c.renderChangeBackupDirView())
+	case state.ImportManifestView:
+		body.WriteString(c.renderImportManifestView())
+	case state.FixConfigView:
+		body.WriteString(c.fixConfigHandler.View())
+	case state.FixConfigFieldView:
+		body.WriteString(c.renderFixConfigFieldView())
+	case state.ExportBundleView:
+		body.WriteString(c.renderExportBundleView())
+	case state.ImportBundleView:
+		body.WriteString(c.renderImportBundleView())
+	case state.VerifyView:
+		body.WriteString(c.verifyHandler.View())
+	case state.HealthView:
+		body.WriteString(c.healthHandler.View())
+	case state.PruneView:
+		body.WriteString(c.pruneHandler.View())
+	case state.ReconcileView:
+		body.WriteString(c.reconcileHandler.View())
+	case state.TrashView:
+		body.WriteString(c.trashHandler.View())
+	case state.GamePickerView:
+		body.WriteString(c.gamePickerHandler.View())
+	case state.FilterPreviewView:
+		body.WriteString(c.previewHandler.View())
+	case state.EditNoteView:
+		body.WriteString(c.renderEditNoteView())
+	case state.EditTagsView:
+		body.WriteString(c.renderEditTagsView())
 	default:
 		// Fallback for any unhandled states
 		body.WriteString("View not implemented yet")
@@ -165,7 +361,7 @@ func (c *Controller) currentView() string {
 	help := c.getHelpText()
 	
 	// Combine title and body
-	title := c.app.GetStyles().Title.Render("Game Save Backup Manager")
+	title := c.renderHeader()
 	content := fmt.Sprintf("%s\n\n%s", title, body.String())
 	
 	// Calculate available space and position help at bottom
@@ -189,24 +385,78 @@ func (c *Controller) getHelpText() string {
 	styles := c.app.GetStyles()
 	
 	switch c.app.GetCurrentState() {
+	case state.FirstRunDiscoverView:
+		if c.app.IsDiscovering() {
+			return ""
+		}
+		return styles.Help.Render("↑/↓: navigate, enter: use this game, /: filter, s: enter path manually")
 	case state.FirstRunView:
+		if c.app.HasCandidates() {
+			return styles.Help.Render("enter: confirm, esc: back to installed games")
+		}
 		return styles.Help.Render("Press 'enter' to confirm.")
 	case state.FirstRunBackupDirView:
 		return styles.Help.Render("Press 'enter' to confirm.")
 	case state.MainMenuView:
+		if len(c.app.GetGames()) > 1 {
+			return styles.Help.Render("tab: next game, ctrl+c: quit")
+		}
 		return styles.Help.Render("Press 'ctrl+c' to quit.")
 	case state.BackupListView:
-		return styles.Help.Render("↑/↓: navigate, enter: restore backup, q: back")
+		return styles.Help.Render("↑/↓: navigate, enter: restore backup, n: edit note, t: edit tags, x: export, /: filter, q: back")
 	case state.ViewBackupsView:
-		return styles.Help.Render("↑/↓: navigate, q: back")
+		return styles.Help.Render("↑/↓: navigate, n: edit note, t: edit tags, x: export, /: filter, q: back")
 	case state.DeletingView:
-		return styles.Help.Render("space: toggle, →: select all, ←: deselect all, enter: confirm, q: back")
+		return styles.Help.Render("space: toggle, →: select all, ←: deselect all, enter: confirm, x: export selected, q: back")
 	case state.DeleteConfirmationView:
 		return styles.Help.Render("y: confirm deletion, n/q: cancel")
 	case state.SettingsView:
-		return styles.Help.Render("1-3: select option, q: back")
+		return styles.Help.Render("1-8: select option, q: back")
 	case state.CreateBackupView:
 		return styles.Help.Render("enter: create backup (empty for auto-name), esc: cancel")
+	case state.VerifyView:
+		return styles.Help.Render("↑/↓: navigate, /: filter, r: verify again, q: back")
+	case state.PruneView:
+		return styles.Help.Render("↑/↓: navigate, y: prune, n/q: cancel")
+	case state.ReconcileView:
+		return styles.Help.Render("a: adopt stray files, d: drop missing records, c: clean unused objects, r: rescan, q: back")
+	case state.HealthView:
+		if c.app.IsCheckingHealth() {
+			return ""
+		}
+		return styles.Help.Render("r: check again, q: back")
+	case state.TrashView:
+		return styles.Help.Render("space: toggle, →: select all, ←: deselect all, r: restore, p: purge permanently, q: back")
+	case state.GamePickerView:
+		if c.app.GetPendingImport() != nil {
+			return styles.Help.Render("↑/↓: navigate, enter: import into game, /: filter, q: skip them")
+		}
+		return styles.Help.Render("↑/↓: navigate, enter: switch to game, /: filter, q: back")
+	case state.FilterPreviewView:
+		if c.app.PreviewCreatesBackup() {
+			return styles.Help.Render("↑/↓: navigate, /: filter, y: create backup, n/q: cancel")
+		}
+		return styles.Help.Render("↑/↓: navigate, /: filter, q: back")
+	case state.FixConfigView:
+		if c.app.GetConfigProblems().OnlyGames() {
+			return styles.Help.Render("↑/↓: navigate, enter: fix, r: check again, c: continue anyway, ctrl+c: quit")
+		}
+		return styles.Help.Render("↑/↓: navigate, enter: fix, r: check again, ctrl+c: quit")
+	case state.FixConfigFieldView:
+		return styles.Help.Render("enter: save, esc: cancel")
+	case state.ImportManifestView:
+		if c.app.IsImportingManifest() {
+			return ""
+		}
+		return styles.Help.Render("enter: import, esc: cancel")
+	case state.ExportBundleView:
+		return styles.Help.Render("enter: export, esc: cancel")
+	case state.ImportBundleView:
+		return styles.Help.Render("enter: import, esc: cancel")
+	case state.EditNoteView:
+		return styles.Help.Render("enter: save note (empty to clear), esc: cancel")
+	case state.EditTagsView:
+		return styles.Help.Render("enter: save tags (empty to clear), esc: cancel")
 	case state.InitializingView:
 		return "" // No help text during initialization
 	default:
@@ -241,6 +491,9 @@ func (c *Controller) handleOtherViews(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return c, nil
 }
 
+// pathHint explains the shorthand accepted in path inputs
+const pathHint = "(~, $VARIABLES and placeholders such as <home>, <xdgData> and <steam> are expanded when used)"
+
 // renderFirstRunView renders the first run setup view
 func (c *Controller) renderFirstRunView() string {
 	width, _ := c.app.GetWindowDimensions()
@@ -251,9 +504,23 @@ func (c *Controller) renderFirstRunView() string {
 	
 	inputStyle := c.app.GetStyles().TextInput.Width(inputWidth)
 	
+	if candidate := c.app.ChosenCandidate(); candidate != nil {
+		prefix := ""
+		if candidate.Prefix != "" {
+			prefix = "<prefix> is " + candidate.Prefix + "\n"
+		}
+		return "Setting up " + candidate.Name + " (" + candidate.Source + ")\n\n" +
+			"This is where the game most likely keeps its saves. Check it and\n" +
+			"narrow it down to the save folder if you can:\n" +
+			prefix +
+			pathHint + "\n\n" +
+			inputStyle.Render(c.app.GetTextInput().View())
+	}
+	
 	return "Welcome to Game Save Backup Manager!\n\n" +
 		"This appears to be your first time running the application.\n" +
-		"Please enter the path to your game's save files:\n\n" +
+		"Please enter the path to your game's save files:\n" +
+		pathHint + "\n\n" +
 		inputStyle.Render(c.app.GetTextInput().View())
 }
 
@@ -269,7 +536,8 @@ func (c *Controller) renderFirstRunBackupDirView() string {
 	
 	return "Setup Complete - Step 2 of 2\n\n" +
 		"Now please enter the directory where you want to store your backups:\n" +
-		"(This can be any folder on your computer)\n\n" +
+		"(This can be any folder on your computer)\n" +
+		pathHint + "\n\n" +
 		inputStyle.Render(c.app.GetTextInput().View())
 }
 
@@ -314,22 +582,27 @@ func (c *Controller) renderDeleteConfirmationView() string {
 	
 	return fmt.Sprintf("Delete Confirmation\n\n"+
 		"Are you sure you want to delete %d backup(s)?\n"+
-		"This action cannot be undone.\n\n"+
+		"They will be moved to the trash and purged after %d day(s).\n\n"+
 		"Press 'y' to confirm deletion\n"+
-		"Press 'n' or 'q' to cancel", count)
+		"Press 'n' or 'q' to cancel", count, c.app.GetConfig().TrashRetentionDays())
 }
 
 // renderSettingsView renders the settings view
 func (c *Controller) renderSettingsView() string {
 	autoBackupStatus := "OFF"
-	if c.app.GetConfig().AutoBackup {
+	if game := c.app.ActiveGame(); game != nil && game.AutoBackup {
 		autoBackupStatus = "ON"
 	}
 	
 	return "Settings\n\n" +
 		"1. Change Save Path\n" +
 		"2. Change Backup Directory\n" +
-		"3. Auto-Backup Before Restore: " + autoBackupStatus
+		"3. Auto-Backup Before Restore: " + autoBackupStatus + "\n" +
+		"4. Reconcile Backups With Disk\n" +
+		"5. Preview Backup Files\n" +
+		"6. Import Save Locations From Manifest\n" +
+		"7. Import Backups From Bundle\n" +
+		"8. Run Diagnostics"
 }
 
 // renderChangeSavePathView renders the change save path view
@@ -343,7 +616,8 @@ func (c *Controller) renderChangeSavePathView() string {
 	inputStyle := c.app.GetStyles().TextInput.Width(inputWidth)
 	
 	return "Change Save Path\n\n" +
-		"Enter the new path to your game's save files:\n\n" +
+		"Enter the new path to your game's save files:\n" +
+		pathHint + "\n\n" +
 		inputStyle.Render(c.app.GetTextInput().View())
 }
 
@@ -358,7 +632,132 @@ func (c *Controller) renderChangeBackupDirView() string {
 	inputStyle := c.app.GetStyles().TextInput.Width(inputWidth)
 	
 	return "Change Backup Directory\n\n" +
-		"Enter the new backup directory path:\n\n" +
+		"Enter the new backup directory path:\n" +
+		pathHint + "\n\n" +
+		inputStyle.Render(c.app.GetTextInput().View())
+}
+
+// renderImportManifestView renders the manifest import view
+func (c *Controller) renderImportManifestView() string {
+	width, _ := c.app.GetWindowDimensions()
+	inputWidth := width - 8 // Leave some margin
+	if inputWidth < 20 {
+		inputWidth = 20 // Minimum width
+	}
+	
+	inputStyle := c.app.GetStyles().TextInput.Width(inputWidth)
+	
+	if c.app.IsImportingManifest() {
+		return "Import Save Locations From Manifest\n\n" +
+			"Reading manifest and looking for saves..."
+	}
+	
+	return "Import Save Locations From Manifest\n\n" +
+		"Enter the path to a Ludusavi-style manifest.yaml. A game profile is\n" +
+		"added for every game in it whose saves are found on this computer:\n" +
+		pathHint + "\n\n" +
+		inputStyle.Render(c.app.GetTextInput().View())
+}
+
+// renderExportBundleView renders the bundle export view
+func (c *Controller) renderExportBundleView() string {
+	width, _ := c.app.GetWindowDimensions()
+	inputWidth := width - 8 // Leave some margin
+	if inputWidth < 20 {
+		inputWidth = 20 // Minimum width
+	}
+	
+	inputStyle := c.app.GetStyles().TextInput.Width(inputWidth)
+	exporting := c.app.GetExporting()
+	
+	what := exporting[0].Name
+	if len(exporting) > 1 {
+		what = fmt.Sprintf("%d backups", len(exporting))
+	}
+	return "Export Backups\n\n" +
+		"Enter the file to write " + what + " to. The bundle can be imported\n" +
+		"on another computer from Settings or with the import command:\n" +
+		pathHint + "\n\n" +
+		inputStyle.Render(c.app.GetTextInput().View())
+}
+
+// renderImportBundleView renders the bundle import view
+func (c *Controller) renderImportBundleView() string {
+	width, _ := c.app.GetWindowDimensions()
+	inputWidth := width - 8 // Leave some margin
+	if inputWidth < 20 {
+		inputWidth = 20 // Minimum width
+	}
+	
+	inputStyle := c.app.GetStyles().TextInput.Width(inputWidth)
+	
+	return "Import Backups From Bundle\n\n" +
+		"Enter the path to an exported bundle. Backups go to the game with the\n" +
+		"same ID or name, and you pick a game for any others; ones already here\n" +
+		"are skipped:\n" +
+		pathHint + "\n\n" +
+		inputStyle.Render(c.app.GetTextInput().View())
+}
+
+// renderFixConfigFieldView renders the editor for a field with a problem
+func (c *Controller) renderFixConfigFieldView() string {
+	width, _ := c.app.GetWindowDimensions()
+	inputWidth := width - 8 // Leave some margin
+	if inputWidth < 20 {
+		inputWidth = 20 // Minimum width
+	}
+	
+	inputStyle := c.app.GetStyles().TextInput.Width(inputWidth)
+	problem := c.app.GetFixingField()
+	
+	hint := ""
+	switch problem.Field {
+	case "backup_dir", "save_path", "games":
+		hint = pathHint + "\n"
+	case "include", "exclude":
+		hint = "(separate patterns with commas)\n"
+	}
+	if problem.Field == "games" {
+		return "Add a Game\n\n" +
+			"Enter the path to your game's save files:\n" +
+			hint + "\n" +
+			inputStyle.Render(c.app.GetTextInput().View())
+	}
+	
+	return "Fix " + problem.Path() + "\n\n" +
+		c.app.GetStyles().Warning.Render(problem.Message) + "\n\n" +
+		"Enter the corrected value:\n" +
+		hint + "\n" +
+		inputStyle.Render(c.app.GetTextInput().View())
+}
+
+// renderEditNoteView renders the note editor for a backup
+func (c *Controller) renderEditNoteView() string {
+	width, _ := c.app.GetWindowDimensions()
+	inputWidth := width - 8 // Leave some margin
+	if inputWidth < 20 {
+		inputWidth = 20 // Minimum width
+	}
+	
+	inputStyle := c.app.GetStyles().TextInput.Width(inputWidth)
+	
+	return "Edit Note\n\n" +
+		"Note for " + c.app.GetEditingBackup().Name + ":\n\n" +
+		inputStyle.Render(c.app.GetTextInput().View())
+}
+
+// renderEditTagsView renders the tag editor for a backup
+func (c *Controller) renderEditTagsView() string {
+	width, _ := c.app.GetWindowDimensions()
+	inputWidth := width - 8 // Leave some margin
+	if inputWidth < 20 {
+		inputWidth = 20 // Minimum width
+	}
+	
+	inputStyle := c.app.GetStyles().TextInput.Width(inputWidth)
+	
+	return "Edit Tags\n\n" +
+		"Tags for " + c.app.GetEditingBackup().Name + " (separate with commas):\n\n" +
 		inputStyle.Render(c.app.GetTextInput().View())
 }
 
@@ -368,7 +767,21 @@ func (c *Controller) isTextInputView(currentState state.ViewState) bool {
 		currentState == state.FirstRunBackupDirView ||
 		currentState == state.CreateBackupView ||
 		currentState == state.ChangeSavePathView ||
-		currentState == state.ChangeBackupDirView
+		currentState == state.ChangeBackupDirView ||
+		currentState == state.ImportManifestView ||
+		currentState == state.FixConfigFieldView ||
+		currentState == state.ExportBundleView ||
+		currentState == state.ImportBundleView ||
+		currentState == state.EditNoteView ||
+		currentState == state.EditTagsView
+}
+
+// isOptionalTextInputView checks if the current state accepts an empty value
+func (c *Controller) isOptionalTextInputView(currentState state.ViewState) bool {
+	return currentState == state.CreateBackupView ||
+		currentState == state.FixConfigFieldView ||
+		currentState == state.EditNoteView ||
+		currentState == state.EditTagsView
 }
 
 // isListView checks if the current state uses list
@@ -382,12 +795,39 @@ func (c *Controller) isListView(currentState state.ViewState) bool {
 func (c *Controller) handleTextInputView(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	
+	// Wait for the manifest to be read
+	if c.app.IsImportingManifest() {
+		return c, nil
+	}
+	
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "enter":
 			return c.handleTextInputSubmit()
 		case "esc":
+			// Editors opened from a list go back to that list
+			if c.app.IsInAnyState(state.EditNoteView, state.EditTagsView) {
+				if err := c.app.FinishEdit(); err != nil {
+					c.app.SetError(err)
+				}
+				return c, nil
+			}
+			// Exports go back to the list the backups were picked from
+			if c.app.IsInAnyState(state.ExportBundleView) {
+				c.app.CancelExport()
+				return c, nil
+			}
+			// Field editors go back to the list of configuration problems
+			if c.app.IsInAnyState(state.FixConfigFieldView) {
+				c.app.CancelFixField()
+				return c, nil
+			}
+			// Go back to the list of installed games during setup
+			if c.app.IsInAnyState(state.FirstRunView) && c.app.HasCandidates() {
+				c.app.TransitionToState(state.FirstRunDiscoverView)
+				return c, nil
+			}
 			// Cancel and go back to main menu
 			c.app.TransitionToState(state.MainMenuView)
 			return c, nil
@@ -406,13 +846,16 @@ func (c *Controller) handleTextInputSubmit() (tea.Model, tea.Cmd) {
 	currentState := c.app.GetCurrentState()
 	inputValue := c.app.GetTextInput().Value()
 	
-	// Don't proceed if input is empty for required fields (not backup creation)
-	if strings.TrimSpace(inputValue) == "" && currentState != state.CreateBackupView {
+	// Don't proceed if input is empty for required fields
+	if strings.TrimSpace(inputValue) == "" && !c.isOptionalTextInputView(currentState) {
 		return c, nil
 	}
 	
 	switch currentState {
 	case state.FirstRunView:
+		if cmd := c.checkPath(inputValue); cmd != nil {
+			return c, cmd
+		}
 		// Save the save path temporarily and move to backup directory setup
 		c.app.SetTempSavePath(inputValue)
 		c.app.TransitionToState(state.FirstRunBackupDirView)
@@ -422,12 +865,29 @@ func (c *Controller) handleTextInputSubmit() (tea.Model, tea.Cmd) {
 		return c, nil
 		
 	case state.FirstRunBackupDirView:
+		if cmd := c.checkPath(inputValue); cmd != nil {
+			return c, cmd
+		}
 		// Now we have both paths, save config and initialize
 		return c.handleFirstRunComplete(inputValue)
 		
 	case state.CreateBackupView:
 		// Create backup with the given name (or empty for auto-generated name)
 		backupName := strings.TrimSpace(inputValue)
+		
+		// Show which files will be included before a game's first backup
+		needsPreview, err := c.app.NeedsFilterPreview()
+		if err != nil {
+			c.app.SetError(fmt.Errorf("failed to create backup: %v", err))
+			return c, nil
+		}
+		if needsPreview {
+			if err := c.app.StartFilterPreview(backupName, true); err != nil {
+				c.app.SetError(fmt.Errorf("failed to create backup: %v", err))
+			}
+			return c, nil
+		}
+		
 		if err := c.app.CreateBackup(backupName); err != nil {
 			c.app.SetError(fmt.Errorf("failed to create backup: %v", err))
 			return c, nil
@@ -442,6 +902,9 @@ func (c *Controller) handleTextInputSubmit() (tea.Model, tea.Cmd) {
 		return c, notificationCmd
 		
 	case state.ChangeSavePathView:
+		if cmd := c.checkPath(inputValue); cmd != nil {
+			return c, cmd
+		}
 		// Update save path
 		if err := c.app.UpdateSavePath(inputValue); err != nil {
 			c.app.SetError(fmt.Errorf("failed to update save path: %v", err))
@@ -452,6 +915,9 @@ func (c *Controller) handleTextInputSubmit() (tea.Model, tea.Cmd) {
 		return c, notificationCmd
 		
 	case state.ChangeBackupDirView:
+		if cmd := c.checkPath(inputValue); cmd != nil {
+			return c, cmd
+		}
 		// Update backup directory
 		if err := c.app.UpdateBackupDir(inputValue); err != nil {
 			c.app.SetError(fmt.Errorf("failed to update backup directory: %v", err))
@@ -460,20 +926,110 @@ func (c *Controller) handleTextInputSubmit() (tea.Model, tea.Cmd) {
 		notificationCmd := c.app.ShowNotification("Backup directory updated: " + inputValue)
 		c.app.TransitionToState(state.SettingsView)
 		return c, notificationCmd
+		
+	case state.ImportManifestView:
+		if cmd := c.checkPath(inputValue); cmd != nil {
+			return c, cmd
+		}
+		return c, c.app.StartImportManifest(strings.TrimSpace(inputValue))
+		
+	case state.ExportBundleView:
+		if cmd := c.checkPath(inputValue); cmd != nil {
+			return c, cmd
+		}
+		count := len(c.app.GetExporting())
+		path, err := c.app.ExportBackups(strings.TrimSpace(inputValue))
+		if err != nil {
+			// Leave the input open so another path can be tried
+			return c, c.app.ShowNotification("Cannot export backups: " + err.Error())
+		}
+		return c, c.app.ShowNotification(fmt.Sprintf("Exported %d backup(s) to %s", count, path))
+		
+	case state.ImportBundleView:
+		if cmd := c.checkPath(inputValue); cmd != nil {
+			return c, cmd
+		}
+		results, err := c.app.ImportBundle(strings.TrimSpace(inputValue))
+		if err != nil {
+			return c, c.app.ShowNotification("Cannot import backups: " + err.Error())
+		}
+		if c.app.GetPendingImport() != nil {
+			// Some backups belong to a game that isn't configured here
+			c.gamePickerHandler.OpenForImport()
+		} else {
+			c.app.TransitionToState(state.SettingsView)
+		}
+		return c, c.app.ShowNotification(views.ImportSummary(results))
+		
+	case state.FixConfigFieldView:
+		cmd, err := c.app.SaveFixedField(inputValue)
+		if err != nil {
+			c.app.SetError(fmt.Errorf("failed to save configuration: %v", err))
+			return c, nil
+		}
+		return c, cmd
+		
+	case state.EditNoteView:
+		if err := c.app.SaveNote(inputValue); err != nil {
+			c.app.SetError(fmt.Errorf("failed to update note: %v", err))
+			return c, nil
+		}
+		return c, c.app.ShowNotification("Note updated")
+		
+	case state.EditTagsView:
+		if err := c.app.SaveTags(inputValue); err != nil {
+			c.app.SetError(fmt.Errorf("failed to update tags: %v", err))
+			return c, nil
+		}
+		return c, c.app.ShowNotification("Tags updated")
 	}
 	
 	return c, nil
 }
 
+// checkPath makes sure a typed path can be expanded. Paths are saved as
+// typed, so problems such as an unknown placeholder are reported here and
+// the input stays open for correction.
+func (c *Controller) checkPath(path string) tea.Cmd {
+	opts := paths.Options{}
+	if game := c.app.ActiveGame(); game != nil && c.app.GetCurrentState() == state.ChangeSavePathView {
+		opts.Prefix = game.Prefix
+	}
+	if candidate := c.app.ChosenCandidate(); candidate != nil && c.app.GetCurrentState() == state.FirstRunView {
+		opts.Prefix = candidate.Prefix
+	}
+	if _, err := paths.Expand(strings.TrimSpace(path), opts); err != nil {
+		return c.app.ShowNotification("Invalid path: " + err.Error())
+	}
+	return nil
+}
+
 // handleListView handles list-based views
 func (c *Controller) handleListView(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// While typing a filter, keys belong to the filter input
+		if c.app.GetList().SettingFilter() {
+			break
+		}
 		switch msg.String() {
 		case "enter":
 			return c.handleListSelection()
+		case "n":
+			if c.app.IsInAnyState(state.BackupListView, state.ViewBackupsView) {
+				c.app.StartEditNote()
+				return c, nil
+			}
+		case "t":
+			if c.app.IsInAnyState(state.BackupListView, state.ViewBackupsView) {
+				c.app.StartEditTags()
+				return c, nil
+			}
+		case "x":
+			c.app.StartExport()
+			return c, nil
 		case " ":
 			if c.app.GetCurrentState() == state.DeletingView {
 				return c.handleToggleSelection()
@@ -530,34 +1086,21 @@ func (c *Controller) handleListSelection() (tea.Model, tea.Cmd) {
 
 // handleToggleSelection toggles selection of current item in delete view
 func (c *Controller) handleToggleSelection() (tea.Model, tea.Cmd) {
-	list := c.app.GetList()
-	index := list.Index()
-	selections := c.app.GetSelections()
-	
-	if _, exists := selections[index]; exists {
-		delete(selections, index)
-	} else {
-		selections[index] = struct{}{}
-	}
+	c.app.ToggleSelection()
 	
 	// Update the list delegate with new selections
-	c.app.SetListDelegate(components.NewSelectableItemDelegate(selections))
+	c.app.SetListDelegate(components.NewSelectableItemDelegate(c.app.GetSelections()))
 	
 	return c, nil
 }
 
 // handleSelectAll selects all items in delete view
 func (c *Controller) handleSelectAll() (tea.Model, tea.Cmd) {
-	list := c.app.GetList()
-	selections := c.app.GetSelections()
-	
-	// Select all items
-	for i := 0; i < len(list.Items()); i++ {
-		selections[i] = struct{}{}
-	}
+	// Select all items the filter shows
+	c.app.SelectAllVisible()
 	
 	// Update the list delegate with new selections
-	c.app.SetListDelegate(components.NewSelectableItemDelegate(selections))
+	c.app.SetListDelegate(components.NewSelectableItemDelegate(c.app.GetSelections()))
 	
 	return c, nil
 }
@@ -597,11 +1140,41 @@ func (c *Controller) handleSettingsView(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return c, nil
 			}
 			status := "OFF"
-			if c.app.GetConfig().AutoBackup {
+			if c.app.ActiveGame().AutoBackup {
 				status = "ON"
 			}
 			notificationCmd := c.app.ShowNotification("Auto-backup setting: " + status)
 			return c, notificationCmd
+		case "4":
+			if err := c.app.RunReconcile(); err != nil {
+				c.app.SetError(fmt.Errorf("failed to scan backups: %v", err))
+				return c, nil
+			}
+			c.app.TransitionToState(state.ReconcileView)
+			return c, nil
+		case "5":
+			if err := c.app.StartFilterPreview("", false); err != nil {
+				return c, c.app.ShowNotification(fmt.Sprintf("Cannot preview save files: %v", err))
+			}
+			return c, nil
+		case "6":
+			c.app.TransitionToState(state.ImportManifestView)
+			c.app.SetTextInputPlaceholder("Enter manifest path, e.g. ~/manifest.yaml")
+			c.app.ClearTextInput()
+			c.app.GetTextInput().SetValue(c.app.GetConfig().Manifest)
+			c.app.GetTextInput().CursorEnd()
+			c.app.FocusTextInput()
+			return c, nil
+		case "7":
+			c.app.TransitionToState(state.ImportBundleView)
+			c.app.SetTextInputPlaceholder("Enter bundle path, e.g. ~/backups.tar")
+			c.app.SetTextInputCharLimit(0)
+			c.app.ClearTextInput()
+			c.app.FocusTextInput()
+			return c, nil
+		case "8":
+			c.app.TransitionToState(state.HealthView)
+			return c, c.app.StartHealthCheck()
 		}
 	}
 	
@@ -614,12 +1187,20 @@ func (c *Controller) handleFirstRunComplete(backupDirPath string) (tea.Model, te
 	savePath := c.app.GetTempSavePath()
 	
 	// Update the config with both paths
-	config := c.app.GetConfig()
-	config.SavePath = savePath
-	config.BackupDir = backupDirPath
+	cfg := c.app.GetConfig()
+	name := config.DefaultGameName
+	candidate := c.app.ChosenCandidate()
+	if candidate != nil {
+		name = candidate.Name
+	}
+	game := cfg.AddGame(name, savePath)
+	if candidate != nil {
+		game.Prefix = candidate.Prefix
+	}
+	cfg.BackupDir = backupDirPath
 	
 	// Save the config to disk
-	if err := config.Save(); err != nil {
+	if err := cfg.Save(); err != nil {
 		c.app.SetError(fmt.Errorf("failed to save configuration: %v", err))
 		return c, nil
 	}
@@ -644,7 +1225,7 @@ func (c *Controller) handleDeleteConfirmationView(msg tea.Msg) (tea.Model, tea.C
 				c.app.SetError(fmt.Errorf("failed to delete backups: %v", err))
 				return c, nil
 			}
-			notificationCmd := c.app.ShowNotification(fmt.Sprintf("Deleted %d backup(s)", len(selections)))
+			notificationCmd := c.app.ShowNotification(fmt.Sprintf("Moved %d backup(s) to the trash", len(selections)))
 			c.app.TransitionToState(state.MainMenuView)
 			return c, notificationCmd
 		case "n", "N", "q":
@@ -0,0 +1,56 @@
+package views
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/app"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/components"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/discovery"
+)
+
+// DiscoverHandler handles the first-run view offering the installed games
+// found on this machine
+type DiscoverHandler struct {
+	app *app.Application
+}
+
+// NewDiscoverHandler creates a new discover handler
+func NewDiscoverHandler(app *app.Application) *DiscoverHandler {
+	return &DiscoverHandler{app: app}
+}
+
+// Update handles discover view input and returns commands
+func (h *DiscoverHandler) Update(msg tea.Msg) tea.Cmd {
+	if h.app.IsDiscovering() {
+		return nil
+	}
+	list := h.app.GetList()
+
+	if msg, ok := msg.(tea.KeyMsg); ok && !list.SettingFilter() {
+		switch msg.String() {
+		case "enter":
+			if item, ok := list.SelectedItem().(components.CandidateItem); ok {
+				candidate := discovery.Candidate(item)
+				h.app.ChooseCandidate(&candidate)
+			}
+			return nil
+		case "s":
+			h.app.ChooseCandidate(nil)
+			return nil
+		}
+	}
+
+	var cmd tea.Cmd
+	*list, cmd = list.Update(msg)
+	return cmd
+}
+
+// View renders the discover view
+func (h *DiscoverHandler) View() string {
+	if h.app.IsDiscovering() {
+		return "Looking for installed games..."
+	}
+	return "Welcome to Game Save Backup Manager!\n\n" +
+		"These games were found on this computer. Pick the one to back up,\n" +
+		"or press 's' to enter a save path yourself.\n\n" +
+		h.app.GetList().View()
+}
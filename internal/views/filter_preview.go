@@ -0,0 +1,80 @@
+package views
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/app"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/backup"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/components"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/state"
+)
+
+// FilterPreviewHandler handles the view listing which save files the active
+// game's include/exclude patterns select
+type FilterPreviewHandler struct {
+	app *app.Application
+}
+
+// NewFilterPreviewHandler creates a new filter preview handler
+func NewFilterPreviewHandler(app *app.Application) *FilterPreviewHandler {
+	return &FilterPreviewHandler{app: app}
+}
+
+// Update handles filter preview input and returns commands
+func (h *FilterPreviewHandler) Update(msg tea.Msg) tea.Cmd {
+	list := h.app.GetList()
+
+	if msg, ok := msg.(tea.KeyMsg); ok && !list.SettingFilter() && h.app.PreviewCreatesBackup() {
+		switch msg.String() {
+		case "y", "Y":
+			name, err := h.app.ConfirmFilterPreview()
+			if err != nil {
+				h.app.SetError(fmt.Errorf("failed to create backup: %v", err))
+				return nil
+			}
+			h.app.TransitionToState(state.MainMenuView)
+			if name == "" {
+				return h.app.ShowNotification("Backup created successfully with auto-generated name")
+			}
+			return h.app.ShowNotification("Backup created successfully: " + name)
+		case "n", "N":
+			h.app.TransitionToState(state.MainMenuView)
+			return nil
+		}
+	}
+
+	var cmd tea.Cmd
+	*list, cmd = list.Update(msg)
+	return cmd
+}
+
+// View renders the filter preview
+func (h *FilterPreviewHandler) View() string {
+	preview := h.app.GetFilterPreview()
+	if preview == nil {
+		return "Scanning save files..."
+	}
+
+	styles := h.app.GetStyles()
+	summary := fmt.Sprintf("%d file(s), %s, will be backed up.",
+		len(preview.Included), components.FormatSize(totalSize(preview.Included)))
+	if n := len(preview.Excluded); n > 0 {
+		summary += styles.Warning.Render(fmt.Sprintf(" %d file(s), %s, are excluded.",
+			n, components.FormatSize(totalSize(preview.Excluded))))
+	}
+	if h.app.PreviewCreatesBackup() {
+		summary += "\nThis is the first backup of this game. Press 'y' to create it or 'n' to cancel."
+	}
+
+	return summary + "\n\n" + h.app.GetList().View()
+}
+
+// totalSize adds up the sizes of preview files
+func totalSize(files []backup.PreviewFile) int64 {
+	var n int64
+	for _, f := range files {
+		n += f.Size
+	}
+	return n
+}
@@ -0,0 +1,69 @@
+package views
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/app"
+)
+
+// FixConfigHandler handles the view listing problems found in the
+// configuration at startup, where each field can be corrected in place
+type FixConfigHandler struct {
+	app *app.Application
+}
+
+// NewFixConfigHandler creates a new fix config handler
+func NewFixConfigHandler(app *app.Application) *FixConfigHandler {
+	return &FixConfigHandler{app: app}
+}
+
+// Update handles fix config input and returns commands
+func (h *FixConfigHandler) Update(msg tea.Msg) tea.Cmd {
+	list := h.app.GetList()
+
+	if msg, ok := msg.(tea.KeyMsg); ok && !list.SettingFilter() {
+		switch msg.String() {
+		case "enter":
+			return h.app.StartFixField()
+		case "r":
+			h.app.RecheckConfig()
+			if len(h.app.GetConfigProblems()) == 0 {
+				return h.continueStartup()
+			}
+			return nil
+		case "c":
+			if h.app.GetConfigProblems().OnlyGames() {
+				return h.continueStartup()
+			}
+			return nil
+		}
+	}
+
+	var cmd tea.Cmd
+	*list, cmd = list.Update(msg)
+	return cmd
+}
+
+// continueStartup leaves the view and initializes the database
+func (h *FixConfigHandler) continueStartup() tea.Cmd {
+	cmd, err := h.app.ContinueStartup()
+	if err != nil {
+		h.app.SetError(fmt.Errorf("failed to save configuration: %v", err))
+		return nil
+	}
+	return cmd
+}
+
+// View renders the fix config view
+func (h *FixConfigHandler) View() string {
+	problems := h.app.GetConfigProblems()
+	header := fmt.Sprintf("Your configuration has %d problem(s). Select one and press 'enter' to fix it.\n",
+		len(problems))
+	header += "Config file: " + h.app.GetConfig().Path()
+	if problems.OnlyGames() {
+		header += "\n" + h.app.GetStyles().Warning.Render(
+			"Only game settings are affected. Press 'c' to continue anyway.")
+	}
+	return header + "\n\n" + h.app.GetList().View()
+}
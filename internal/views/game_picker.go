@@ -0,0 +1,146 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/app"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/backup"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/components"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/config"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/state"
+)
+
+// GamePickerHandler handles the view for choosing the active game
+type GamePickerHandler struct {
+	app *app.Application
+}
+
+// NewGamePickerHandler creates a new game picker handler
+func NewGamePickerHandler(app *app.Application) *GamePickerHandler {
+	return &GamePickerHandler{app: app}
+}
+
+// Open fills the list with the configured games and highlights the active one
+func (h *GamePickerHandler) Open() {
+	h.app.ClearPendingImport()
+	h.open("Select a game")
+}
+
+// OpenForImport lets the user pick the game to import the backups of a
+// bundle into whose game matches none of the configured ones
+func (h *GamePickerHandler) OpenForImport() {
+	count := 0
+	var names []string
+	seen := make(map[string]bool)
+	for _, r := range h.app.GetPendingImport() {
+		if r.Status != backup.ImportNoGame {
+			continue
+		}
+		count++
+		name := r.Backup.Game.Name
+		if name == "" {
+			name = r.Backup.Game.ID
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	h.open(fmt.Sprintf("No game matches %s. Import its %d backup(s) into:", strings.Join(names, ", "), count))
+}
+
+// open fills the list with the configured games under title
+func (h *GamePickerHandler) open(title string) {
+	games := h.app.GetGames()
+	active := h.app.ActiveGame()
+
+	items := make([]list.Item, len(games))
+	selected := 0
+	for i, g := range games {
+		isActive := active != nil && g.ID == active.ID
+		if isActive {
+			selected = i
+		}
+		items[i] = components.GameItem{Game: g, Active: isActive}
+	}
+
+	h.app.TransitionToState(state.GamePickerView)
+	h.app.SetListDelegate(components.NewNormalItemDelegate())
+	h.app.SetListItems(title, items)
+	h.app.GetList().ResetFilter()
+	h.app.GetList().Select(selected)
+}
+
+// Update handles game picker input and returns commands
+func (h *GamePickerHandler) Update(msg tea.Msg) tea.Cmd {
+	list := h.app.GetList()
+
+	if msg, ok := msg.(tea.KeyMsg); ok && !list.SettingFilter() && msg.String() == "enter" {
+		item, ok := list.SelectedItem().(components.GameItem)
+		if !ok {
+			return nil
+		}
+		if h.app.GetPendingImport() != nil {
+			return h.importInto(item.Game)
+		}
+		if err := h.app.SwitchGame(item.Game.ID); err != nil {
+			h.app.SetError(fmt.Errorf("failed to switch game: %v", err))
+			return nil
+		}
+		h.app.TransitionToState(state.MainMenuView)
+		return h.app.ShowNotification("Switched to " + item.Game.Name)
+	}
+
+	var cmd tea.Cmd
+	*list, cmd = list.Update(msg)
+	return cmd
+}
+
+// importInto adds the backups waiting for a game to game
+func (h *GamePickerHandler) importInto(game config.Game) tea.Cmd {
+	results, err := h.app.ImportUnmatched(game.ID)
+	h.app.TransitionToState(state.SettingsView)
+	if err != nil {
+		return h.app.ShowNotification("Cannot import backups: " + err.Error())
+	}
+	return h.app.ShowNotification(ImportSummary(results))
+}
+
+// ImportSummary describes the outcome of a bundle import in one line
+func ImportSummary(results []backup.ImportResult) string {
+	imported, present, unmatched, skipped := 0, 0, 0, 0
+	for _, r := range results {
+		switch {
+		case r.Imported():
+			imported++
+		case r.Status == backup.ImportDuplicate:
+			present++
+		case r.Status == backup.ImportNoGame:
+			unmatched++
+		default:
+			skipped++
+		}
+	}
+	summary := fmt.Sprintf("Imported %d backup(s)", imported)
+	if present > 0 {
+		summary += fmt.Sprintf(", %d already present", present)
+	}
+	if unmatched > 0 {
+		summary += fmt.Sprintf(", %d skipped with no matching game", unmatched)
+	}
+	if skipped > 0 {
+		summary += fmt.Sprintf(", %d skipped", skipped)
+	}
+	return summary
+}
+
+// View renders the game picker
+func (h *GamePickerHandler) View() string {
+	if len(h.app.GetGames()) == 0 {
+		return "No games are configured. Add one to the \"games\" section of config.json."
+	}
+	return h.app.GetList().View()
+}
@@ -0,0 +1,69 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/app"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/doctor"
+)
+
+// HealthHandler handles the diagnostics view
+type HealthHandler struct {
+	app *app.Application
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(app *app.Application) *HealthHandler {
+	return &HealthHandler{app: app}
+}
+
+// Update handles health view input and returns commands
+func (h *HealthHandler) Update(msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "r" && !h.app.IsCheckingHealth() {
+		return h.app.StartHealthCheck()
+	}
+	return nil
+}
+
+// View renders the health view
+func (h *HealthHandler) View() string {
+	report := h.app.GetHealthReport()
+	if h.app.IsCheckingHealth() || report == nil {
+		return "Running diagnostics..."
+	}
+
+	styles := h.app.GetStyles()
+	var b strings.Builder
+	b.WriteString("Diagnostics\n\n")
+	for _, c := range report.Checks {
+		status := fmt.Sprintf("%-7s", c.Status)
+		switch c.Status {
+		case doctor.StatusOK:
+			status = styles.Success.Render(status)
+		case doctor.StatusWarning:
+			status = styles.Warning.Render(status)
+		case doctor.StatusFailed:
+			status = styles.Error.Render(status)
+		}
+		b.WriteString(fmt.Sprintf("%s  %s: %s\n", status, c.Name, c.Detail))
+		if c.Hint != "" {
+			b.WriteString(fmt.Sprintf("         Fix: %s\n", c.Hint))
+		}
+	}
+
+	summary := fmt.Sprintf("%d OK, %d warning(s), %d failed, %d skipped",
+		report.Count(doctor.StatusOK), report.Count(doctor.StatusWarning),
+		report.Count(doctor.StatusFailed), report.Count(doctor.StatusSkipped))
+	switch {
+	case !report.Healthy():
+		summary = styles.Error.Render(summary)
+	case report.Count(doctor.StatusWarning) > 0:
+		summary = styles.Warning.Render(summary)
+	default:
+		summary = styles.Success.Render(summary)
+	}
+	b.WriteString("\n" + summary)
+	return b.String()
+}
@@ -32,6 +32,14 @@ func (h *MainMenuHandler) Update(msg tea.Msg) tea.Cmd {
 			return h.handleDeleteBackups()
 		case "5":
 			return h.handleSettings()
+		case "6":
+			return h.handleVerifyBackups()
+		case "7":
+			return h.handlePruneBackups()
+		case "8":
+			return h.handleTrash()
+		case "9":
+			return h.handleSwitchGame()
 		}
 	}
 	return nil
@@ -44,7 +52,11 @@ func (h *MainMenuHandler) View() string {
 		"2. Restore Backup\n" +
 		"3. List Backups\n" +
 		"4. Delete Backups\n" +
-		"5. Settings"
+		"5. Settings\n" +
+		"6. Verify Backups\n" +
+		"7. Prune Backups\n" +
+		"8. Trash\n" +
+		"9. Switch Game"
 }
 
 // handleCreateBackup transitions to create backup view
@@ -65,7 +77,7 @@ func (h *MainMenuHandler) handleRestoreBackup() tea.Cmd {
 	h.app.TransitionToState(state.BackupListView)
 	h.app.SetListDelegate(components.NewNormalItemDelegate())
 	cmd := h.app.RefreshBackupList("Select a backup to restore")
-	h.app.ResetListSelection()
+	h.app.RestoreListPosition()
 	return cmd
 }
 
@@ -74,7 +86,7 @@ func (h *MainMenuHandler) handleListBackups() tea.Cmd {
 	h.app.TransitionToState(state.ViewBackupsView)
 	h.app.SetListDelegate(components.NewNormalItemDelegate())
 	cmd := h.app.RefreshBackupList("Available Backups")
-	h.app.ResetListSelection()
+	h.app.RestoreListPosition()
 	return cmd
 }
 
@@ -84,6 +96,7 @@ func (h *MainMenuHandler) handleDeleteBackups() tea.Cmd {
 	h.app.ClearSelections()
 	h.app.SetListDelegate(components.NewSelectableItemDelegate(h.app.GetSelections()))
 	cmd := h.app.RefreshBackupList("Select backups to delete")
+	h.app.GetList().ResetFilter()
 	h.app.ResetListSelection()
 	return cmd
 }
@@ -92,4 +105,47 @@ func (h *MainMenuHandler) handleDeleteBackups() tea.Cmd {
 func (h *MainMenuHandler) handleSettings() tea.Cmd {
 	h.app.TransitionToState(state.SettingsView)
 	return nil
+}
+
+// handleVerifyBackups transitions to the verify view and starts the integrity check
+func (h *MainMenuHandler) handleVerifyBackups() tea.Cmd {
+	h.app.TransitionToState(state.VerifyView)
+	h.app.SetListDelegate(components.NewNormalItemDelegate())
+	cmd := h.app.StartVerify()
+	h.app.ResetListSelection()
+	return cmd
+}
+
+// handlePruneBackups computes the retention plan and transitions to the prune preview
+func (h *MainMenuHandler) handlePruneBackups() tea.Cmd {
+	cfg := h.app.GetConfig()
+	if cfg.RetentionFor(h.app.ActiveGame()).IsEmpty() {
+		return h.app.ShowNotification("No retention rules configured. Add a \"retention\" section to config.json.")
+	}
+
+	h.app.SetListDelegate(components.NewNormalItemDelegate())
+	if err := h.app.PlanPrune(); err != nil {
+		return func() tea.Msg { return err }
+	}
+	h.app.TransitionToState(state.PruneView)
+	h.app.ResetListSelection()
+	return nil
+}
+
+// handleTrash transitions to the trash view
+func (h *MainMenuHandler) handleTrash() tea.Cmd {
+	h.app.TransitionToState(state.TrashView)
+	h.app.ClearSelections()
+	h.app.SetConfirmingPurge(false)
+	h.app.SetListDelegate(components.NewSelectableItemDelegate(h.app.GetSelections()))
+	cmd := h.app.RefreshTrashList()
+	h.app.GetList().ResetFilter()
+	h.app.ResetListSelection()
+	return cmd
+}
+
+// handleSwitchGame opens the game picker
+func (h *MainMenuHandler) handleSwitchGame() tea.Cmd {
+	NewGamePickerHandler(h.app).Open()
+	return nil
 }
\ No newline at end of file
@@ -0,0 +1,69 @@
+package views
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/app"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/retention"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/state"
+)
+
+// PruneHandler handles the retention preview and pruning view
+type PruneHandler struct {
+	app *app.Application
+}
+
+// NewPruneHandler creates a new prune handler
+func NewPruneHandler(app *app.Application) *PruneHandler {
+	return &PruneHandler{app: app}
+}
+
+// Update handles prune view input and returns commands
+func (h *PruneHandler) Update(msg tea.Msg) tea.Cmd {
+	list := h.app.GetList()
+
+	if msg, ok := msg.(tea.KeyMsg); ok && !list.SettingFilter() {
+		switch msg.String() {
+		case "y", "Y":
+			if len(retention.Removals(h.app.GetPrunePlan())) == 0 {
+				return nil
+			}
+			count, err := h.app.ExecutePrune()
+			if err != nil {
+				h.app.SetError(fmt.Errorf("failed to prune backups: %v", err))
+				return nil
+			}
+			h.app.TransitionToState(state.MainMenuView)
+			return h.app.ShowNotification(fmt.Sprintf("Pruned %d backup(s)", count))
+		case "n", "N":
+			h.app.TransitionToState(state.MainMenuView)
+			return nil
+		}
+	}
+
+	var cmd tea.Cmd
+	*list, cmd = list.Update(msg)
+	return cmd
+}
+
+// View renders the prune view
+func (h *PruneHandler) View() string {
+	plan := h.app.GetPrunePlan()
+	if len(plan) == 0 {
+		return "There are no backups to prune."
+	}
+
+	remove := len(retention.Removals(plan))
+	styles := h.app.GetStyles()
+
+	var summary string
+	if remove == 0 {
+		summary = styles.Success.Render(fmt.Sprintf("All %d backup(s) are kept by the retention policy.", len(plan)))
+	} else {
+		summary = styles.Warning.Render(fmt.Sprintf("%d backup(s) will be removed, %d kept.", remove, len(plan)-remove)) +
+			"\nPress 'y' to prune or 'n' to cancel."
+	}
+
+	return summary + "\n\n" + h.app.GetList().View()
+}
@@ -0,0 +1,117 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/app"
+)
+
+// maxReconcileEntries limits how many entries of each kind are listed
+const maxReconcileEntries = 8
+
+// ReconcileHandler handles the database/disk reconciliation view
+type ReconcileHandler struct {
+	app *app.Application
+}
+
+// NewReconcileHandler creates a new reconcile handler
+func NewReconcileHandler(app *app.Application) *ReconcileHandler {
+	return &ReconcileHandler{app: app}
+}
+
+// Update handles reconcile view input and returns commands
+func (h *ReconcileHandler) Update(msg tea.Msg) tea.Cmd {
+	report := h.app.GetReconcileReport()
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || report == nil {
+		return nil
+	}
+
+	var (
+		count  int
+		err    error
+		action string
+	)
+	switch keyMsg.String() {
+	case "a":
+		if len(report.Orphans) == 0 {
+			return nil
+		}
+		count, err = h.app.AdoptOrphans()
+		action = "Adopted %d stray backup file(s)"
+	case "d":
+		if len(report.Missing) == 0 {
+			return nil
+		}
+		count, err = h.app.DropMissingBackups()
+		action = "Removed %d backup record(s) with missing data"
+	case "c":
+		if len(report.UnusedObjects) == 0 {
+			return nil
+		}
+		count, err = h.app.RemoveUnusedObjects()
+		action = "Removed %d unreferenced object(s)"
+	case "r":
+		if err := h.app.RunReconcile(); err != nil {
+			h.app.SetError(fmt.Errorf("failed to scan backups: %v", err))
+		}
+		return nil
+	default:
+		return nil
+	}
+
+	if err != nil {
+		h.app.SetError(fmt.Errorf("failed to reconcile backups: %v", err))
+		return nil
+	}
+	return h.app.ShowNotification(fmt.Sprintf(action, count))
+}
+
+// View renders the reconcile view
+func (h *ReconcileHandler) View() string {
+	report := h.app.GetReconcileReport()
+	if report == nil {
+		return "Scanning backups..."
+	}
+
+	styles := h.app.GetStyles()
+	if report.Clean() {
+		return "Reconcile Backups\n\n" + styles.Success.Render("The database matches the files on disk.")
+	}
+
+	var b strings.Builder
+	b.WriteString("Reconcile Backups\n")
+
+	if n := len(report.Missing); n > 0 {
+		b.WriteString("\n" + styles.Error.Render(fmt.Sprintf("%d backup(s) recorded in the database have missing data on disk:", n)) + "\n")
+		for i, m := range report.Missing {
+			if i == maxReconcileEntries {
+				b.WriteString(fmt.Sprintf("  ...and %d more\n", n-i))
+				break
+			}
+			b.WriteString(fmt.Sprintf("  %s (%s)\n", m.Name, m.CreatedAt.Format("2006-01-02 15:04:05")))
+		}
+		b.WriteString("  Press 'd' to drop these records.\n")
+	}
+
+	if n := len(report.Orphans); n > 0 {
+		b.WriteString("\n" + styles.Warning.Render(fmt.Sprintf("%d backup file(s) on disk are not in the database:", n)) + "\n")
+		for i, o := range report.Orphans {
+			if i == maxReconcileEntries {
+				b.WriteString(fmt.Sprintf("  ...and %d more\n", n-i))
+				break
+			}
+			b.WriteString(fmt.Sprintf("  %s (modified %s)\n", o.Path, o.ModTime.Format("2006-01-02 15:04:05")))
+		}
+		b.WriteString("  Press 'a' to adopt them as backups.\n")
+	}
+
+	if n := len(report.UnusedObjects); n > 0 {
+		b.WriteString("\n" + styles.Warning.Render(fmt.Sprintf("%d object(s) in the store are not used by any backup.", n)) + "\n")
+		b.WriteString("  Press 'c' to clean them up.\n")
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
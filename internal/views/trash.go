@@ -0,0 +1,108 @@
+package views
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/app"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/components"
+)
+
+// TrashHandler handles the trash view, where deleted backups can be
+// restored or purged permanently
+type TrashHandler struct {
+	app *app.Application
+}
+
+// NewTrashHandler creates a new trash handler
+func NewTrashHandler(app *app.Application) *TrashHandler {
+	return &TrashHandler{app: app}
+}
+
+// Update handles trash view input and returns commands
+func (h *TrashHandler) Update(msg tea.Msg) tea.Cmd {
+	list := h.app.GetList()
+
+	if msg, ok := msg.(tea.KeyMsg); ok && !list.SettingFilter() {
+		if h.app.IsConfirmingPurge() {
+			return h.handlePurgeConfirmation(msg)
+		}
+
+		selections := h.app.GetSelections()
+		switch msg.String() {
+		case " ":
+			h.app.ToggleSelection()
+			h.app.SetListDelegate(components.NewSelectableItemDelegate(selections))
+			return nil
+		case "right", "→":
+			h.app.SelectAllVisible()
+			h.app.SetListDelegate(components.NewSelectableItemDelegate(selections))
+			return nil
+		case "left", "←":
+			h.app.ClearSelections()
+			h.app.SetListDelegate(components.NewSelectableItemDelegate(h.app.GetSelections()))
+			return nil
+		case "r":
+			if len(selections) == 0 {
+				return nil
+			}
+			count, err := h.app.RestoreSelectedFromTrash()
+			if err != nil {
+				h.app.SetError(fmt.Errorf("failed to restore backups from trash: %v", err))
+				return nil
+			}
+			return tea.Batch(h.refresh(), h.app.ShowNotification(fmt.Sprintf("Restored %d backup(s) from the trash", count)))
+		case "p":
+			if len(selections) > 0 {
+				h.app.SetConfirmingPurge(true)
+			}
+			return nil
+		}
+	}
+
+	var cmd tea.Cmd
+	*list, cmd = list.Update(msg)
+	return cmd
+}
+
+// handlePurgeConfirmation handles the y/n prompt before a permanent delete
+func (h *TrashHandler) handlePurgeConfirmation(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "y", "Y":
+		count, err := h.app.PurgeSelectedFromTrash()
+		if err != nil {
+			h.app.SetError(fmt.Errorf("failed to purge backups: %v", err))
+			return nil
+		}
+		return tea.Batch(h.refresh(), h.app.ShowNotification(fmt.Sprintf("Permanently deleted %d backup(s)", count)))
+	case "n", "N", "esc":
+		h.app.SetConfirmingPurge(false)
+	}
+	return nil
+}
+
+// refresh reloads the trash and clears the selection
+func (h *TrashHandler) refresh() tea.Cmd {
+	h.app.ClearSelections()
+	h.app.SetListDelegate(components.NewSelectableItemDelegate(h.app.GetSelections()))
+	cmd := h.app.RefreshTrashList()
+	h.app.ResetListSelection()
+	return cmd
+}
+
+// View renders the trash view
+func (h *TrashHandler) View() string {
+	list := h.app.GetList()
+	if len(list.Items()) == 0 {
+		return "The trash is empty."
+	}
+
+	days := h.app.GetConfig().TrashRetentionDays()
+	header := fmt.Sprintf("Deleted backups are purged permanently after %d day(s).", days)
+	if h.app.IsConfirmingPurge() {
+		header = h.app.GetStyles().Warning.Render(fmt.Sprintf(
+			"Permanently delete %d backup(s)? This cannot be undone. (y/n)", len(h.app.GetSelections())))
+	}
+
+	return header + "\n\n" + list.View()
+}
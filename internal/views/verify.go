@@ -0,0 +1,82 @@
+package views
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/app"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/backup"
+	"github.com/vedicodes/game-save-backup-manager-reimagined/internal/components"
+)
+
+// VerifyHandler handles the backup integrity verification view
+type VerifyHandler struct {
+	app *app.Application
+}
+
+// NewVerifyHandler creates a new verify handler
+func NewVerifyHandler(app *app.Application) *VerifyHandler {
+	return &VerifyHandler{app: app}
+}
+
+// Update handles verify view input and returns commands
+func (h *VerifyHandler) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case app.VerifyCompletedMsg:
+		h.app.SetVerifyResults(msg.Results)
+
+		items := make([]list.Item, len(msg.Results))
+		for i, r := range msg.Results {
+			items[i] = components.VerifyItem(r)
+		}
+		h.app.SetListItems("Verification Results", items)
+		h.app.ResetListSelection()
+		return nil
+	case tea.KeyMsg:
+		if h.app.IsVerifying() {
+			return nil
+		}
+		if msg.String() == "r" && !h.app.GetList().SettingFilter() {
+			return h.app.StartVerify()
+		}
+	}
+
+	if h.app.IsVerifying() {
+		return nil
+	}
+	list := h.app.GetList()
+	var cmd tea.Cmd
+	*list, cmd = list.Update(msg)
+	return cmd
+}
+
+// View renders the verify view
+func (h *VerifyHandler) View() string {
+	if h.app.IsVerifying() {
+		return "Verifying backups..."
+	}
+
+	results := h.app.GetVerifyResults()
+	if len(results) == 0 {
+		return "There are no backups to verify."
+	}
+
+	counts := make(map[backup.VerifyStatus]int)
+	for _, r := range results {
+		counts[r.Status]++
+	}
+
+	styles := h.app.GetStyles()
+	summary := fmt.Sprintf("Checked %d backup(s): %d OK", len(results), counts[backup.VerifyOK])
+	problems := counts[backup.VerifyMissing] + counts[backup.VerifyCorrupted] + counts[backup.VerifyMismatch]
+	if problems > 0 {
+		summary += styles.Error.Render(fmt.Sprintf(", %d missing, %d corrupted, %d mismatched",
+			counts[backup.VerifyMissing], counts[backup.VerifyCorrupted], counts[backup.VerifyMismatch]))
+	}
+	if n := counts[backup.VerifyUnchecked]; n > 0 {
+		summary += styles.Warning.Render(fmt.Sprintf(", %d without checksum", n))
+	}
+
+	return summary + "\n\n" + h.app.GetList().View()
+}